@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	auditAction string
+	auditEmail  string
+	auditSince  time.Duration
+	auditLimit  int32
+	auditFollow bool
+)
+
+var cmdAudit = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log",
+}
+
+var cmdAuditTail = &cobra.Command{
+	Use:   "tail",
+	Short: "Print the most recent audit events",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		email := strings.ToLower(auditEmail)
+
+		params := dbx.ListAuditEventsParams{
+			Action:   auditAction,
+			Email:    email,
+			RowLimit: auditLimit,
+		}
+		if auditSince > 0 {
+			params.Since = pgtype.Timestamptz{Time: time.Now().Add(-auditSince), Valid: true}
+		}
+
+		events, err := queries.ListAuditEvents(ctx, params)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var lastID int64
+		for _, event := range slices.Backward(events) {
+			printAuditEvent(event)
+			lastID = max(lastID, event.ID)
+		}
+
+		for auditFollow {
+			time.Sleep(2 * time.Second)
+
+			after, err := queries.ListAuditEventsAfter(ctx, dbx.ListAuditEventsAfterParams{
+				Action:   auditAction,
+				Email:    email,
+				AfterID:  lastID,
+				RowLimit: 100,
+			})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, event := range after {
+				printAuditEvent(dbx.ListAuditEventsRow(event))
+				lastID = event.ID
+			}
+		}
+	},
+}
+
+// printAuditEvent print an audit event on a single line.
+func printAuditEvent(event dbx.ListAuditEventsRow) {
+	actor, subject := event.ActorEmail, event.SubjectEmail
+	if actor == "" {
+		actor = "-"
+	}
+	if subject == "" {
+		subject = "-"
+	}
+	ip := event.Ip
+	if ip == "" {
+		ip = "-"
+	}
+
+	fmt.Printf("%s  %-26s actor=%s subject=%s ip=%s %s\n",
+		event.CreatedAt.Time.Format(time.RFC3339), event.Action, actor, subject, ip, event.Metadata)
+}
+
+// recordCLI append an event made from the command line to the audit log,
+// warning rather than failing the command if it cannot be recorded.
+func recordCLI(ctx context.Context, queries *dbx.Queries, event audit.Event) {
+	if event.Metadata == nil {
+		event.Metadata = map[string]any{}
+	}
+	event.Metadata["source"] = "cli"
+
+	if err := audit.Record(ctx, queries, event); err != nil {
+		fmt.Printf("Warning: unable to record audit event: %v\n", err)
+	}
+}
+
+func init() {
+	cmdAuditTail.Flags().StringVarP(&auditAction, "action", "a", "", "Only events whose action starts with this, e.g. auth. or auth.login")
+	cmdAuditTail.Flags().StringVarP(&auditEmail, "email", "e", "", "Only events where this user is the actor or subject")
+	cmdAuditTail.Flags().DurationVarP(&auditSince, "since", "s", 0, "Only events within this long ago, e.g. 24h")
+	cmdAuditTail.Flags().Int32VarP(&auditLimit, "limit", "n", 20, "The number of events to print")
+	cmdAuditTail.Flags().BoolVarP(&auditFollow, "follow", "f", false, "Keep printing new events as they are recorded")
+	cmdAudit.AddCommand(cmdAuditTail)
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"gin.go.dev/pkg/audit"
 	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/mail"
 	"gin.go.dev/pkg/storage/db/dbx"
 	"github.com/jackc/pgx/v5"
 	"github.com/spf13/cobra"
@@ -12,6 +14,7 @@ import (
 
 var (
 	createEmail, createPassword, createFirstName, createLastName string
+	createVerified                                               bool
 )
 
 var cmdCreateUser = &cobra.Command{
@@ -45,6 +48,29 @@ var cmdCreateUser = &cobra.Command{
 			os.Exit(1)
 		}
 
+		if createVerified {
+			if err = queries.SetUserVerifiedByID(ctx, user.ID); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			mailer, err := mail.New(cfg.Mail)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err = auth.SendVerification(ctx, queries, mailer, cfg.Server.BaseURL, user); err != nil {
+				fmt.Printf("Error sending verification email: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		recordCLI(ctx, queries, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionUserCreated,
+			Metadata: map[string]any{"email": user.Email, "is_verified": createVerified},
+		})
+
 		fmt.Printf("User created!: %v\n", user.Email)
 	},
 }
@@ -54,6 +80,7 @@ func init() {
 	cmdCreateUser.Flags().StringVarP(&createPassword, "password", "p", "", "The password of the user")
 	cmdCreateUser.Flags().StringVarP(&createFirstName, "firstname", "f", "", "The first name of the user")
 	cmdCreateUser.Flags().StringVarP(&createLastName, "lastname", "l", "", "The last name of the user")
+	cmdCreateUser.Flags().BoolVar(&createVerified, "verified", false, "Mark the user as verified instead of sending a verification email")
 	_ = cmdCreateUser.MarkFlagRequired("email")
 	_ = cmdCreateUser.MarkFlagRequired("password")
 	_ = cmdCreateUser.MarkFlagRequired("firstname")
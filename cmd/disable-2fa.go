@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	disable2FAEmail string
+)
+
+var cmdDisable2FA = &cobra.Command{
+	Use:   "disable2fa",
+	Short: "Disable a user's two-factor authentication",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		user, err := queries.GetUserByEmail(ctx, strings.ToLower(disable2FAEmail))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err = auth.DisableTwoFactor(ctx, conn, queries, user.ID); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Two-factor authentication disabled for user: %v\n", user.Email)
+	},
+}
+
+func init() {
+	cmdDisable2FA.Flags().StringVarP(&disable2FAEmail, "email", "e", "", "The email address of the user")
+	_ = cmdDisable2FA.MarkFlagRequired("email")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	grantRoleEmail string
+	grantRoleName  string
+)
+
+var cmdGrantRole = &cobra.Command{
+	Use:   "grantrole",
+	Short: "Grant a role to a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		user, err := queries.GetUserByEmail(ctx, strings.ToLower(grantRoleEmail))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		role, err := queries.GetRoleByName(ctx, grantRoleName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err = queries.GrantUserRole(ctx, dbx.GrantUserRoleParams{UserID: user.ID, RoleID: role.ID}); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Role %v granted to: %v\n", role.Name, user.Email)
+	},
+}
+
+func init() {
+	cmdGrantRole.Flags().StringVarP(&grantRoleEmail, "email", "e", "", "The email address of the user")
+	cmdGrantRole.Flags().StringVarP(&grantRoleName, "role", "r", "", "The name of the role")
+	_ = cmdGrantRole.MarkFlagRequired("email")
+	_ = cmdGrantRole.MarkFlagRequired("role")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	inviteEmail string
+)
+
+var cmdInvite = &cobra.Command{
+	Use:   "invite",
+	Short: "Invites a new user to register",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		if !cfg.Registration.Enabled {
+			fmt.Println("Error: registration is disabled, enable it to send invites")
+			os.Exit(1)
+		}
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		mailer, err := mail.New(cfg.Mail)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		queries := dbx.New(conn)
+		if err = auth.SendInvite(ctx, queries, mailer, cfg.Server.BaseURL, inviteEmail); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Invite sent to: %v\n", inviteEmail)
+	},
+}
+
+func init() {
+	cmdInvite.Flags().StringVarP(&inviteEmail, "email", "e", "", "The email address to invite")
+	_ = cmdInvite.MarkFlagRequired("email")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"gin.go.dev/pkg/api"
+	"gin.go.dev/pkg/mail"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	openapiOutput string
+)
+
+var cmdOpenAPI = &cobra.Command{
+	Use:   "openapi",
+	Short: "Writes the OpenAPI document of the JSON API",
+	Run: func(cmd *cobra.Command, args []string) {
+		gin.SetMode(gin.ReleaseMode)
+
+		mailer, err := mail.New(cfg.Mail)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		doc := api.Router(gin.New(), cfg, mailer)
+
+		b, err := doc.JSON()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err = os.WriteFile(openapiOutput, b, 0o644); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("OpenAPI document written to: %v\n", openapiOutput)
+	},
+}
+
+func init() {
+	cmdOpenAPI.Flags().StringVarP(&openapiOutput, "output", "o", "openapi.json", "The file to write the document to")
+}
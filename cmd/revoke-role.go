@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	revokeRoleEmail string
+	revokeRoleName  string
+)
+
+var cmdRevokeRole = &cobra.Command{
+	Use:   "revokerole",
+	Short: "Revoke a role from a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		user, err := queries.GetUserByEmail(ctx, strings.ToLower(revokeRoleEmail))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		role, err := queries.GetRoleByName(ctx, revokeRoleName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		revoked, err := queries.RevokeUserRole(ctx, dbx.RevokeUserRoleParams{UserID: user.ID, RoleID: role.ID})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if revoked == 0 {
+			fmt.Printf("Error: %v does not have the role %v\n", user.Email, role.Name)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Role %v revoked from: %v\n", role.Name, user.Email)
+	},
+}
+
+func init() {
+	cmdRevokeRole.Flags().StringVarP(&revokeRoleEmail, "email", "e", "", "The email address of the user")
+	cmdRevokeRole.Flags().StringVarP(&revokeRoleName, "role", "r", "", "The name of the role")
+	_ = cmdRevokeRole.MarkFlagRequired("email")
+	_ = cmdRevokeRole.MarkFlagRequired("role")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"gin.go.dev/pkg/auth"
 	"gin.go.dev/pkg/config"
 	"github.com/spf13/cobra"
 	"os"
@@ -30,7 +31,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is config.toml)")
 	rootCmd.AddCommand(cmdServer)
 	rootCmd.AddCommand(cmdCreateUser)
+	rootCmd.AddCommand(cmdInvite)
 	rootCmd.AddCommand(cmdSetPassword)
+	rootCmd.AddCommand(cmdDisable2FA)
+	rootCmd.AddCommand(cmdUnlockUser)
+	rootCmd.AddCommand(cmdGrantRole)
+	rootCmd.AddCommand(cmdRevokeRole)
+	rootCmd.AddCommand(cmdAudit)
+	rootCmd.AddCommand(cmdToken)
+	rootCmd.AddCommand(cmdOpenAPI)
 	rootCmd.AddCommand(cmdMigrate)
 }
 
@@ -45,6 +54,11 @@ func initConfig() {
 		fmt.Printf("Can't read config: %v\n", cfgErr)
 		os.Exit(1)
 	}
+
+	if err := auth.ConfigurePasswords(cfg.Password); err != nil {
+		fmt.Printf("Invalid password config: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func Execute() {
@@ -4,9 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"gin.go.dev/pkg/admin"
+	"gin.go.dev/pkg/api"
 	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/config"
 	"gin.go.dev/pkg/home"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/org"
 	"gin.go.dev/pkg/static"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/storage/ratelimit"
+	"gin.go.dev/pkg/storage/session"
 	"gin.go.dev/pkg/transport/html"
 	"gin.go.dev/pkg/transport/middleware"
 	"github.com/gin-contrib/gzip"
@@ -91,7 +99,20 @@ func runServer() {
 		ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
 	})
 
-	sessionStore := cookie.NewStore(cfg.Session.KeyBytes(), cfg.Session.EncKeyBytes())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sessionStore sessions.Store
+	switch cfg.Session.Store {
+	case config.SessionStorePostgres:
+		store := session.NewPostgresStore(dbx.New(dbPool), cfg.Session.KeyBytes(), cfg.Session.EncKeyBytes())
+		go store.Sweep(ctx, time.Hour)
+		sessionStore = store
+	case config.SessionStoreCookie, "":
+		sessionStore = cookie.NewStore(cfg.Session.KeyBytes(), cfg.Session.EncKeyBytes())
+	default:
+		log.Fatalf("Invalid session store '%s'\n", cfg.Session.Store)
+	}
 	sessionStore.Options(sessions.Options{
 		Path:     cfg.Session.Path,
 		Domain:   cfg.Session.Domain,
@@ -102,25 +123,50 @@ func runServer() {
 	})
 	sessionMiddleware := sessions.Sessions("session", sessionStore)
 
+	var rateLimiter ratelimit.Limiter
+	switch cfg.RateLimit.Backend {
+	case config.RateLimitBackendPostgres:
+		limiter := ratelimit.NewPostgresLimiter(dbx.New(dbPool))
+		go limiter.Sweep(ctx, time.Hour)
+		rateLimiter = limiter
+	case config.RateLimitBackendMemory, "":
+		limiter := ratelimit.NewMemoryLimiter(cfg.RateLimit.MaxKeys)
+		go limiter.Sweep(ctx, time.Minute)
+		rateLimiter = limiter
+	default:
+		log.Fatalf("Invalid rate limit backend '%s'\n", cfg.RateLimit.Backend)
+	}
+
 	gzipMiddleware := gzip.Gzip(gzip.DefaultCompression)
 
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Unable to create mail sender: %v\n", err)
+	}
+
 	engine := gin.New()
 
 	initLogging(engine)
 
 	engine.Use(
 		gin.Recovery(),
+		middleware.GinContext(),
 		secureMiddleware,
 		sessionMiddleware,
 		gzipMiddleware,
 		middleware.Context(dbPool),
+		middleware.RateLimits(rateLimiter, cfg.RateLimit),
+		middleware.RememberMe(cfg.RememberMe, cfg.Session),
 	)
 
-	engine.HTMLRender = &html.Render{Fallback: engine.HTMLRender}
+	engine.HTMLRender = &html.Renderer{Fallback: engine.HTMLRender}
 
 	static.Router(engine)
 	home.Router(engine)
-	auth.Router(engine, csrfMiddleware)
+	auth.Router(engine, csrfMiddleware, cfg, mailer)
+	_ = api.Router(engine, cfg, mailer)
+	admin.Router(engine, csrfMiddleware, cfg, mailer)
+	org.Router(engine, csrfMiddleware, cfg, mailer)
 
 	server := http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"gin.go.dev/pkg/audit"
 	"gin.go.dev/pkg/auth"
 	"gin.go.dev/pkg/storage/db/dbx"
 	"github.com/jackc/pgx/v5"
@@ -42,6 +43,30 @@ var cmdSetPassword = &cobra.Command{
 			os.Exit(1)
 		}
 
+		user, err := queries.GetUserByEmail(ctx, setPWEmail)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		revoked, err := queries.DeleteSessionsByUserID(ctx, user.ID)
+		if err != nil {
+			fmt.Printf("Error revoking sessions: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Sessions revoked: %d\n", revoked)
+
+		if err = queries.DeleteRememberTokensByUserID(ctx, user.ID); err != nil {
+			fmt.Printf("Error revoking remember me tokens: %v\n", err)
+			os.Exit(1)
+		}
+
+		recordCLI(ctx, queries, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionPasswordSet,
+			Metadata: map[string]any{"sessions_revoked": revoked},
+		})
+
 		fmt.Printf("Password set for user: %v\n", setPWEmail)
 	},
 }
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	tokenEmail   string
+	tokenName    string
+	tokenScopes  []string
+	tokenExpires time.Duration
+)
+
+var cmdToken = &cobra.Command{
+	Use:   "token",
+	Short: "Manage personal access tokens",
+}
+
+var cmdTokenCreate = &cobra.Command{
+	Use:   "create",
+	Short: "Create a personal access token for a user",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		user, err := queries.GetUserByEmail(ctx, strings.ToLower(tokenEmail))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		token, accessToken, err := auth.CreateAccessToken(ctx, queries, user.ID, tokenName, tokenScopes, tokenExpires)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		recordCLI(ctx, queries, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionTokenCreated,
+			Metadata: map[string]any{"name": accessToken.Name, "prefix": accessToken.Prefix, "scopes": accessToken.Scopes},
+		})
+
+		fmt.Printf("Token created for %v, it will not be shown again:\n%v\n", user.Email, token)
+	},
+}
+
+func init() {
+	cmdTokenCreate.Flags().StringVarP(&tokenEmail, "email", "e", "", "The email address of the user")
+	cmdTokenCreate.Flags().StringVarP(&tokenName, "name", "n", "", "A name to recognise the token by")
+	cmdTokenCreate.Flags().StringSliceVarP(&tokenScopes, "scopes", "s", []string{"read"}, "The scopes of the token, any of "+strings.Join(auth.AccessTokenScopes, ", "))
+	cmdTokenCreate.Flags().DurationVar(&tokenExpires, "expires", 30*24*time.Hour, "How long until the token expires, 0 to never expire")
+	_ = cmdTokenCreate.MarkFlagRequired("email")
+	_ = cmdTokenCreate.MarkFlagRequired("name")
+	cmdToken.AddCommand(cmdTokenCreate)
+}
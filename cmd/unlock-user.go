@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	unlockEmail string
+)
+
+var cmdUnlockUser = &cobra.Command{
+	Use:   "unlockuser",
+	Short: "Unlock a user locked out by failed logins",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		conn, err := pgx.Connect(ctx, cfg.Database.URL().String())
+		if err != nil {
+			fmt.Printf("Error connecting to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close(ctx)
+
+		queries := dbx.New(conn)
+		user, err := queries.GetUserByEmail(ctx, strings.ToLower(unlockEmail))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err = queries.DeleteLockoutByUserID(ctx, user.ID); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("User unlocked: %v\n", user.Email)
+	},
+}
+
+func init() {
+	cmdUnlockUser.Flags().StringVarP(&unlockEmail, "email", "e", "", "The email address of the user")
+	_ = cmdUnlockUser.MarkFlagRequired("email")
+}
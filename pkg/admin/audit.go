@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// eventsPerPage the number of events on each page of the audit log.
+const eventsPerPage = 50
+
+// listAuditEvents get the audit log newest first, filtered by action prefix and user email,
+// paged by the id of the last event on the previous page.
+func listAuditEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+
+	data := pages.AdminAuditData{
+		Action: strings.TrimSpace(c.Query("action")),
+		Email:  strings.ToLower(strings.TrimSpace(c.Query("email"))),
+	}
+	if before, err := strconv.ParseInt(c.Query("before"), 10, 64); err == nil && before > 0 {
+		data.BeforeID = before
+	}
+
+	events, err := queries.ListAuditEvents(ctx, dbx.ListAuditEventsParams{
+		Action:   data.Action,
+		Email:    data.Email,
+		BeforeID: data.BeforeID,
+		RowLimit: eventsPerPage,
+	})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, event := range events {
+		data.Events = append(data.Events, pages.AdminAuditRow{
+			Action:    event.Action,
+			Actor:     event.ActorEmail,
+			Subject:   event.SubjectEmail,
+			IP:        event.Ip,
+			UserAgent: event.UserAgent,
+			Metadata:  string(event.Metadata),
+			CreatedAt: event.CreatedAt.Time,
+		})
+	}
+	if len(events) == eventsPerPage {
+		data.NextID = events[len(events)-1].ID
+	}
+
+	html.Render(c, http.StatusOK, pages.AdminAudit(data))
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/ui/components"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+)
+
+// impersonateUser log in as the user, remembering the admin to switch back to, then redirect to home.
+// Admins cannot impersonate themselves, deactivated users or users with permissions of their own.
+func impersonateUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	queries := appctx.Queries(c)
+	current := appctx.MustCurrentUser(c)
+
+	user, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	invalid := func(message string) {
+		html.Render(c, http.StatusUnprocessableEntity, pages.AdminUser(pages.AdminUserData{
+			ID:         uuid.UUID(user.ID.Bytes).String(),
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			Email:      user.Email,
+			IsActive:   user.IsActive,
+			IsVerified: user.IsVerified,
+			Error:      message,
+			Csrf:       csrf.GetToken(c),
+		}))
+	}
+
+	if user.ID == current.ID {
+		invalid("you cannot impersonate yourself")
+		return
+	}
+	if !user.IsActive {
+		invalid("a deactivated user cannot be impersonated")
+		return
+	}
+
+	permissions, err := queries.ListPermissionNamesByUserID(ctx, user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to impersonate the user")
+		return
+	}
+	if len(permissions) > 0 {
+		invalid("a user with permissions cannot be impersonated")
+		return
+	}
+
+	if err = middleware.Impersonate(c, current, user); err != nil {
+		_ = c.Error(err)
+		invalid("unable to impersonate the user")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  user.ID,
+		Action:   audit.ActionImpersonationStarted,
+		Metadata: map[string]any{"email": user.Email},
+	})
+
+	hx.SetRedirect("/")
+	c.Status(http.StatusOK)
+}
+
+// stopImpersonating switch back to the admin then redirect to the impersonated user.
+func stopImpersonating(c *gin.Context) {
+	adminID, userID, ok, err := middleware.StopImpersonating(c)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Actor:   adminID,
+		Subject: userID,
+		Action:  audit.ActionImpersonationEnded,
+	})
+
+	c.Redirect(http.StatusFound, "/admin/users/"+uuid.UUID(userID.Bytes).String())
+}
+
+// impersonationBanner the banner shown while impersonating, empty otherwise.
+func impersonationBanner(c *gin.Context) {
+	admin, ok := middleware.Impersonator(c)
+	if !ok {
+		c.Status(http.StatusOK)
+		return
+	}
+	user := appctx.MustCurrentUser(c)
+
+	html.Render(c, http.StatusOK, components.ImpersonationBanner(admin.Email, user.Email, csrf.GetToken(c)))
+}
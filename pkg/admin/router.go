@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/openapi"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Router create a new Router, every route requires the permission for its area.
+func Router(e *gin.Engine, csrf gin.HandlerFunc, cfg *config.Config, mailer mail.Sender) {
+	baseURL := cfg.Server.BaseURL
+	allowForm := middleware.AllowContentType("application/x-www-form-urlencoded")
+
+	u := e.Group("/admin/users", middleware.RequirePermission("users.manage"))
+	{
+		u.GET("", csrf, listUsers)
+		u.GET("/new", csrf, newUserForm)
+		u.POST("/new", allowForm, csrf, createUser(mailer, baseURL))
+		u.GET("/:id", csrf, getUser)
+		u.POST("/:id", allowForm, csrf, updateUser(mailer, baseURL))
+		u.POST("/:id/reset-password", allowForm, csrf, resetUserPassword(mailer, baseURL))
+		u.POST("/:id/activate", allowForm, csrf, setUserActive(true))
+		u.POST("/:id/deactivate", allowForm, csrf, setUserActive(false))
+		u.POST("/:id/impersonate", allowForm, csrf, impersonateUser)
+	}
+
+	i := e.Group("/admin/impersonation")
+	{
+		i.GET("", csrf, impersonationBanner)
+		i.POST("/stop", allowForm, csrf, stopImpersonating)
+	}
+
+	a := e.Group("/admin/audit", middleware.RequirePermission("audit.view"))
+	{
+		a.GET("", listAuditEvents)
+	}
+}
+
+// API register and describe the admin JSON endpoints on the versioned API group.
+func API(g *openapi.Group, cfg *config.Config, mailer mail.Sender) {
+	baseURL := cfg.Server.BaseURL
+	allowJSON := middleware.AllowContentType("application/json")
+	read := middleware.RequireScope("read")
+	write := middleware.RequireScope("write")
+
+	u := g.Group("/users", middleware.RequirePermission("users.manage"))
+	{
+		u.GET("", openapi.Operation{
+			ID:       "listUsers",
+			Summary:  "List the users, 25 per page",
+			Query:    UsersQuery{},
+			Response: []auth.UserResource{},
+			Paged:    true,
+		}, read, listUsers)
+		u.POST("", openapi.Operation{
+			ID:          "createUser",
+			Summary:     "Create a user",
+			Description: "The user is emailed a link to set their password, and to verify their email when unverified.",
+			Request:     UserDetails{},
+			Response:    auth.UserResource{},
+			Status:      http.StatusCreated,
+		}, write, allowJSON, createUser(mailer, baseURL))
+		u.GET("/:id", openapi.Operation{
+			ID:       "getUser",
+			Summary:  "Get a user",
+			Response: auth.UserResource{},
+		}, read, getUser)
+		u.PATCH("/:id", openapi.Operation{
+			ID:          "updateUser",
+			Summary:     "Update a user",
+			Description: "Fields left out keep their value. Deactivating a user revokes their sessions. Changing the email address marks it unverified, unless is_verified is given, and emails a verification link.",
+			Request:     UserDetails{},
+			Response:    auth.UserResource{},
+		}, write, allowJSON, updateUser(mailer, baseURL))
+	}
+}
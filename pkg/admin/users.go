@@ -0,0 +1,556 @@
+package admin
+
+import (
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/respond"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+)
+
+// usersPerPage the number of users on each page of the users list.
+const usersPerPage = 25
+
+// UserDetails used in the create and edit user validation
+type UserDetails struct {
+	FirstName  string `form:"first_name" json:"first_name" binding:"required,max=120"`
+	LastName   string `form:"last_name" json:"last_name" binding:"required,max=120"`
+	Email      string `form:"email" json:"email" binding:"required,email,max=320"`
+	IsActive   bool   `form:"is_active" json:"is_active"`
+	IsVerified bool   `form:"is_verified" json:"is_verified"`
+}
+
+// UsersQuery the search and page of the users list.
+type UsersQuery struct {
+	Q    string `form:"q" doc:"Search by name or email"`
+	Page int    `form:"page" doc:"The page, from 1"`
+}
+
+// bindUserDetails bind the user details from the form or JSON body.
+// Fields left out of a JSON body keep the values given, unlike unchecked form checkboxes.
+func bindUserDetails(c *gin.Context, details UserDetails) (UserDetails, error) {
+	if c.ContentType() != gin.MIMEJSON {
+		details = UserDetails{}
+	}
+	var err error
+	if c.ContentType() == gin.MIMEJSON {
+		err = c.ShouldBindBodyWith(&details, binding.JSON)
+	} else {
+		err = c.ShouldBind(&details)
+	}
+	return details, err
+}
+
+// verifiedGiven if the request itself sets is_verified, rather than it keeping the value given to bindUserDetails.
+func verifiedGiven(c *gin.Context) bool {
+	if c.ContentType() != gin.MIMEJSON {
+		_, ok := c.GetPostForm("is_verified")
+		return ok
+	}
+	var given struct {
+		IsVerified *bool `json:"is_verified"`
+	}
+	return c.ShouldBindBodyWith(&given, binding.JSON) == nil && given.IsVerified != nil
+}
+
+// userRow the users list row for a user.
+func userRow(user dbx.AuthUser, current dbx.AuthUser) pages.AdminUserRow {
+	return pages.AdminUserRow{
+		ID:         uuid.UUID(user.ID.Bytes).String(),
+		Name:       user.FirstName + " " + user.LastName,
+		Email:      user.Email,
+		IsActive:   user.IsActive,
+		IsVerified: user.IsVerified,
+		CreatedAt:  user.CreatedAt.Time,
+		Self:       user.ID == current.ID,
+	}
+}
+
+// userChanges the fields changed by an update, for the audit log.
+func userChanges(before dbx.AuthUser, after dbx.AuthUser) map[string]any {
+	changes := map[string]any{}
+	if before.Email != after.Email {
+		changes["email"] = []string{before.Email, after.Email}
+	}
+	if before.FirstName != after.FirstName || before.LastName != after.LastName {
+		changes["name"] = []string{before.FirstName + " " + before.LastName, after.FirstName + " " + after.LastName}
+	}
+	if before.IsActive != after.IsActive {
+		changes["is_active"] = after.IsActive
+	}
+	if before.IsVerified != after.IsVerified {
+		changes["is_verified"] = after.IsVerified
+	}
+	return changes
+}
+
+// userParam get the user from the id in the path, aborts with not found if there is none.
+func userParam(c *gin.Context) (dbx.AuthUser, bool) {
+	queries := appctx.Queries(c)
+
+	notFound := func() (dbx.AuthUser, bool) {
+		if respond.WantsJSON(c) {
+			respond.Error(c, http.StatusNotFound, "not_found", "the user does not exist")
+		} else {
+			c.AbortWithStatus(http.StatusNotFound)
+		}
+		return dbx.AuthUser{}, false
+	}
+
+	var id pgtype.UUID
+	if err := id.Scan(c.Param("id")); err != nil {
+		return notFound()
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		return notFound()
+	}
+	return user, true
+}
+
+// listUsers get the paginated users list, filtered by the search, or the page of users as JSON.
+// HTMX searches and page links select the list from the full page.
+func listUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	current := appctx.MustCurrentUser(c)
+
+	var query UsersQuery
+	_ = c.ShouldBindQuery(&query)
+	search := strings.TrimSpace(query.Q)
+	page := max(1, query.Page)
+
+	total, err := queries.CountUsers(ctx, search)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := max(1, int((total+usersPerPage-1)/usersPerPage))
+	page = min(page, pageCount)
+
+	users, err := queries.ListUsers(ctx, dbx.ListUsersParams{
+		Search:    search,
+		RowLimit:  usersPerPage,
+		RowOffset: int32((page - 1) * usersPerPage),
+	})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if respond.WantsJSON(c) {
+		resources := make([]auth.UserResource, 0, len(users))
+		for _, user := range users {
+			resources = append(resources, auth.NewUserResource(user))
+		}
+		respond.Page(c, resources, respond.PageMeta{Page: page, PerPage: usersPerPage, Total: total})
+		return
+	}
+
+	data := pages.AdminUsersData{
+		Search: search,
+		Page:   page,
+		Pages:  pageCount,
+		Total:  total,
+		Csrf:   csrf.GetToken(c),
+	}
+	for _, user := range users {
+		data.Users = append(data.Users, userRow(user, current))
+	}
+
+	html.Render(c, http.StatusOK, pages.AdminUsers(data))
+}
+
+// newUserForm get the create user form.
+func newUserForm(c *gin.Context) {
+	html.Render(c, http.StatusOK, pages.AdminUser(pages.AdminUserData{
+		IsActive: true,
+		Csrf:     csrf.GetToken(c),
+	}))
+}
+
+// createUser create a user from the form or JSON and email them a link to set their password,
+// along with a verification link if their email address is not marked verified.
+func createUser(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		hx := appctx.HTMX(c)
+		postgres := appctx.Postgres(c)
+		queries := appctx.Queries(c)
+
+		details, bindErr := bindUserDetails(c, UserDetails{IsActive: true})
+
+		email := strings.ToLower(details.Email)
+		invalid := func(status int, code string, message string) {
+			if respond.WantsJSON(c) {
+				respond.Error(c, status, code, message)
+				return
+			}
+			html.Render(c, http.StatusUnprocessableEntity, pages.AdminUser(pages.AdminUserData{
+				FirstName:  details.FirstName,
+				LastName:   details.LastName,
+				Email:      email,
+				IsActive:   details.IsActive,
+				IsVerified: details.IsVerified,
+				Error:      message,
+				Csrf:       csrf.GetToken(c),
+			}))
+		}
+
+		if bindErr != nil {
+			if respond.WantsJSON(c) {
+				respond.Invalid(c, bindErr)
+				return
+			}
+			invalid(http.StatusUnprocessableEntity, "invalid", "please enter a name and a valid email address")
+			return
+		}
+
+		password, _, err := auth.GenerateToken()
+		if err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to create the user")
+			return
+		}
+		hashed, err := auth.GeneratePassword([]byte(password))
+		if err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to create the user")
+			return
+		}
+
+		tx, err := postgres.Begin(ctx)
+		if err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to create the user")
+			return
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		qtx := queries.WithTx(tx)
+		user, err := qtx.CreateUser(ctx, dbx.CreateUserParams{
+			Email:          email,
+			HashedPassword: hashed,
+			FirstName:      details.FirstName,
+			LastName:       details.LastName,
+		})
+		if err == nil {
+			user, err = qtx.UpdateUser(ctx, dbx.UpdateUserParams{
+				ID:         user.ID,
+				Email:      user.Email,
+				FirstName:  user.FirstName,
+				LastName:   user.LastName,
+				IsActive:   details.IsActive,
+				IsVerified: details.IsVerified,
+			})
+		}
+		if err != nil {
+			if db.IsUniqueViolation(err) {
+				invalid(http.StatusConflict, "email_taken", "an account with this email address already exists")
+				return
+			}
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to create the user")
+			return
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to create the user")
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionAdminUserCreated,
+			Metadata: map[string]any{"email": user.Email, "is_active": user.IsActive, "is_verified": user.IsVerified},
+		})
+
+		if err = auth.SendPasswordReset(ctx, queries, mailer, baseURL, user); err != nil {
+			_ = c.Error(err)
+		}
+		if !user.IsVerified {
+			if err = auth.SendVerification(ctx, queries, mailer, baseURL, user); err != nil {
+				_ = c.Error(err)
+			}
+		}
+
+		if respond.WantsJSON(c) {
+			respond.Data(c, http.StatusCreated, auth.NewUserResource(user))
+			return
+		}
+
+		hx.SetRedirect("/admin/users/" + uuid.UUID(user.ID.Bytes).String())
+		c.Status(http.StatusOK)
+	}
+}
+
+// getUser get the edit user form, or the user as JSON.
+func getUser(c *gin.Context) {
+	user, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	html.Negotiate(c, http.StatusOK, auth.NewUserResource(user), func() templ.Component {
+		return pages.AdminUser(pages.AdminUserData{
+			ID:         uuid.UUID(user.ID.Bytes).String(),
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			Email:      user.Email,
+			IsActive:   user.IsActive,
+			IsVerified: user.IsVerified,
+			Csrf:       csrf.GetToken(c),
+		})
+	})
+}
+
+// updateUser update a user from the form or JSON,
+// deactivating a user signs them out everywhere.
+// Changing their email address marks it unverified, unless verified is set in the same request,
+// and emails them a verification link.
+func updateUser(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		postgres := appctx.Postgres(c)
+		queries := appctx.Queries(c)
+		current := appctx.MustCurrentUser(c)
+
+		user, ok := userParam(c)
+		if !ok {
+			return
+		}
+
+		details, bindErr := bindUserDetails(c, UserDetails{
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			Email:      user.Email,
+			IsActive:   user.IsActive,
+			IsVerified: user.IsVerified,
+		})
+
+		data := pages.AdminUserData{
+			ID:         uuid.UUID(user.ID.Bytes).String(),
+			FirstName:  details.FirstName,
+			LastName:   details.LastName,
+			Email:      strings.ToLower(details.Email),
+			IsActive:   details.IsActive,
+			IsVerified: details.IsVerified,
+		}
+		invalid := func(status int, code string, message string) {
+			if respond.WantsJSON(c) {
+				respond.Error(c, status, code, message)
+				return
+			}
+			data.Error = message
+			data.Csrf = csrf.GetToken(c)
+			html.Render(c, http.StatusUnprocessableEntity, pages.AdminUser(data))
+		}
+
+		if bindErr != nil {
+			if respond.WantsJSON(c) {
+				respond.Invalid(c, bindErr)
+				return
+			}
+			invalid(http.StatusUnprocessableEntity, "invalid", "please enter a name and a valid email address")
+			return
+		}
+
+		if user.ID == current.ID && !details.IsActive {
+			invalid(http.StatusUnprocessableEntity, "deactivate_self", "you cannot deactivate your own account")
+			return
+		}
+
+		emailChanged := data.Email != user.Email
+		if emailChanged && !verifiedGiven(c) {
+			details.IsVerified = false
+			data.IsVerified = false
+		}
+
+		tx, err := postgres.Begin(ctx)
+		if err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to update the user")
+			return
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		qtx := queries.WithTx(tx)
+		updated, err := qtx.UpdateUser(ctx, dbx.UpdateUserParams{
+			ID:         user.ID,
+			Email:      data.Email,
+			FirstName:  details.FirstName,
+			LastName:   details.LastName,
+			IsActive:   details.IsActive,
+			IsVerified: details.IsVerified,
+		})
+		if err != nil {
+			if db.IsUniqueViolation(err) {
+				invalid(http.StatusConflict, "email_taken", "an account with this email address already exists")
+				return
+			}
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to update the user")
+			return
+		}
+
+		if user.IsActive && !details.IsActive {
+			if _, err = qtx.DeleteSessionsByUserID(ctx, user.ID); err != nil {
+				_ = c.Error(err)
+				invalid(http.StatusInternalServerError, "internal", "unable to update the user")
+				return
+			}
+			if err = qtx.DeleteRememberTokensByUserID(ctx, user.ID); err != nil {
+				_ = c.Error(err)
+				invalid(http.StatusInternalServerError, "internal", "unable to update the user")
+				return
+			}
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			_ = c.Error(err)
+			invalid(http.StatusInternalServerError, "internal", "unable to update the user")
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionAdminUserUpdated,
+			Metadata: userChanges(user, updated),
+		})
+
+		if emailChanged && !updated.IsVerified {
+			if err = auth.SendVerification(ctx, queries, mailer, baseURL, updated); err != nil {
+				_ = c.Error(err)
+			}
+		}
+
+		html.Negotiate(c, http.StatusOK, auth.NewUserResource(updated), func() templ.Component {
+			data.Notice = "The user has been saved."
+			data.Csrf = csrf.GetToken(c)
+			return pages.AdminUser(data)
+		})
+	}
+}
+
+// resetUserPassword email the user a link to reset their password.
+func resetUserPassword(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		user, ok := userParam(c)
+		if !ok {
+			return
+		}
+
+		data := pages.AdminUserData{
+			ID:         uuid.UUID(user.ID.Bytes).String(),
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			Email:      user.Email,
+			IsActive:   user.IsActive,
+			IsVerified: user.IsVerified,
+			Notice:     "A password reset link has been sent to " + user.Email + ".",
+			Csrf:       csrf.GetToken(c),
+		}
+
+		if err := auth.SendPasswordReset(ctx, queries, mailer, baseURL, user); err != nil {
+			_ = c.Error(err)
+			data.Notice = ""
+			data.Error = "unable to send the password reset link"
+			html.Render(c, http.StatusUnprocessableEntity, pages.AdminUser(data))
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Subject: user.ID,
+			Action:  audit.ActionAdminPasswordReset,
+		})
+
+		html.Render(c, http.StatusOK, pages.AdminUser(data))
+	}
+}
+
+// setUserActive activate or deactivate a user then render their users list row,
+// deactivating a user signs them out everywhere.
+func setUserActive(active bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		hx := appctx.HTMX(c)
+		postgres := appctx.Postgres(c)
+		queries := appctx.Queries(c)
+		current := appctx.MustCurrentUser(c)
+
+		user, ok := userParam(c)
+		if !ok {
+			return
+		}
+
+		if user.ID == current.ID {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		tx, err := postgres.Begin(ctx)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		qtx := queries.WithTx(tx)
+		if user, err = qtx.SetUserActiveByID(ctx, dbx.SetUserActiveByIDParams{
+			ID:       user.ID,
+			IsActive: active,
+		}); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		if !active {
+			if _, err = qtx.DeleteSessionsByUserID(ctx, user.ID); err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			if err = qtx.DeleteRememberTokensByUserID(ctx, user.ID); err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		action := audit.ActionAdminUserActivated
+		if !active {
+			action = audit.ActionAdminUserDeactivated
+		}
+		audit.RecordRequest(c, audit.Event{
+			Subject: user.ID,
+			Action:  action,
+		})
+
+		if hx.IsHTMXRequest() {
+			html.Render(c, http.StatusOK, pages.AdminUserItem(userRow(user, current), csrf.GetToken(c)))
+			return
+		}
+		c.Redirect(http.StatusFound, "/admin/users")
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"gin.go.dev/pkg/admin"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/openapi"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+)
+
+// Router create a new Router for the versioned JSON API, returning the OpenAPI document describing it.
+// Clients authenticate by access token or session cookie. There is no CSRF token,
+// instead writes only accept JSON bodies or DELETE, which a cross-site form cannot send.
+func Router(e *gin.Engine, cfg *config.Config, mailer mail.Sender) *openapi.Document {
+	doc := openapi.New("Gin API", "1.0.0")
+	doc.Envelopes(respond.PageMeta{}, respond.ErrorEnvelope{})
+
+	v1 := e.Group("/api/v1", respond.JSON(), middleware.BearerAuth())
+	{
+		auth.API(doc.Group(v1, "Auth"), cfg)
+		admin.API(doc.Group(v1, "Admin"), cfg, mailer)
+	}
+
+	e.GET("/api/openapi.json", doc.Handler())
+
+	return doc
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The actions recorded in the audit log, grouped by a dotted prefix for filtering.
+const (
+	ActionLogin                = "auth.login"
+	ActionLoginFailed          = "auth.login_failed"
+	ActionLocked               = "auth.locked"
+	ActionLogout               = "auth.logout"
+	ActionPasswordReset        = "auth.password_reset"
+	ActionMagicLinkSent        = "auth.magic_link_sent"
+	ActionRememberTokenReused  = "auth.remember_token_reused"
+	ActionPasswordSet          = "user.password_set"
+	ActionTokenCreated         = "user.token_created"
+	ActionTokenRevoked         = "user.token_revoked"
+	ActionUserCreated          = "user.created"
+	ActionAdminUserCreated     = "admin.user_created"
+	ActionAdminUserUpdated     = "admin.user_updated"
+	ActionAdminUserActivated   = "admin.user_activated"
+	ActionAdminUserDeactivated = "admin.user_deactivated"
+	ActionAdminPasswordReset   = "admin.password_reset_sent"
+	ActionImpersonationStarted = "admin.impersonation_started"
+	ActionImpersonationEnded   = "admin.impersonation_ended"
+	ActionOrgCreated           = "org.created"
+	ActionOrgMemberInvited     = "org.member_invited"
+	ActionOrgInviteRevoked     = "org.invite_revoked"
+	ActionOrgMemberJoined      = "org.member_joined"
+	ActionOrgMemberRoleChanged = "org.member_role_changed"
+	ActionOrgMemberRemoved     = "org.member_removed"
+)
+
+// Event an entry in the audit log.
+// The actor is who did it and the subject is the user it was done to,
+// either may be empty, e.g. no actor for the cli or an unknown user.
+type Event struct {
+	Actor     pgtype.UUID
+	Subject   pgtype.UUID
+	Action    string
+	IP        string
+	UserAgent string
+	Metadata  map[string]any
+}
+
+// Record append the event to the audit log.
+func Record(ctx context.Context, queries *dbx.Queries, event Event) error {
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return err
+		}
+	}
+
+	return queries.CreateAuditEvent(ctx, dbx.CreateAuditEventParams{
+		ActorID:   event.Actor,
+		SubjectID: event.Subject,
+		Action:    event.Action,
+		Ip:        event.IP,
+		UserAgent: event.UserAgent,
+		Metadata:  metadata,
+	})
+}
+
+// RecordRequest append the event to the audit log with the request's client ip and user agent,
+// the actor defaults to the current user, or the admin impersonating them. Failing to record does not fail the request.
+func RecordRequest(c *gin.Context, event Event) {
+	queries := appctx.Queries(c)
+
+	if err := Record(c.Request.Context(), queries, RequestEvent(c, event)); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// RequestEvent the event with the request's client ip, user agent and default actor as RecordRequest sets them,
+// for recording with Record once the request is over, e.g. after work done in the background.
+func RequestEvent(c *gin.Context, event Event) Event {
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	if !event.Actor.Valid {
+		if admin, ok := appctx.Impersonator(c); ok {
+			event.Actor = admin.ID
+		} else if user, ok := appctx.CurrentUser(c); ok {
+			event.Actor = user.ID
+		}
+	}
+	return event
+}
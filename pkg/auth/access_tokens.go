@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// accessTokenPrefix marks a personal access token so it can be recognised, e.g. by secret scanners.
+const accessTokenPrefix = "pat_"
+
+// AccessTokenScopes the scopes an access token can be granted.
+var AccessTokenScopes = []string{"read", "write"}
+
+// AccessTokenRequest used in the create access token validation
+type AccessTokenRequest struct {
+	Name      string   `form:"name" binding:"required,max=120"`
+	Scopes    []string `form:"scopes" binding:"required,min=1"`
+	ExpiresIn int      `form:"expires_in" binding:"oneof=0 30 90 365"`
+}
+
+// CreateAccessToken create a personal access token for the user, returning the token
+// which is only ever available now as just its hash is stored. A zero ttl never expires.
+func CreateAccessToken(ctx context.Context, queries *dbx.Queries, userID pgtype.UUID, name string, scopes []string, ttl time.Duration) (string, dbx.AuthAccessToken, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(AccessTokenScopes, scope) {
+			return "", dbx.AuthAccessToken{}, errors.New("unknown access token scope " + scope)
+		}
+	}
+
+	token, _, err := GenerateToken()
+	if err != nil {
+		return "", dbx.AuthAccessToken{}, err
+	}
+	token = accessTokenPrefix + token
+
+	var expiresAt pgtype.Timestamptz
+	if ttl > 0 {
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	accessToken, err := queries.CreateAccessToken(ctx, dbx.CreateAccessTokenParams{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    token[:len(accessTokenPrefix)+8],
+		TokenHash: HashToken(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	return token, accessToken, err
+}
+
+// accessTokensData the access tokens page data for the user.
+func accessTokensData(c *gin.Context, user dbx.AuthUser) (pages.AccessTokensData, error) {
+	queries := appctx.Queries(c)
+
+	rows, err := queries.ListAccessTokensByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		return pages.AccessTokensData{}, err
+	}
+
+	data := pages.AccessTokensData{
+		Scopes:    AccessTokenScopes,
+		ExpiresIn: 30,
+		Csrf:      csrf.GetToken(c),
+	}
+	for _, row := range rows {
+		data.Tokens = append(data.Tokens, pages.AccessTokenRow{
+			ID:         uuid.UUID(row.ID.Bytes).String(),
+			Name:       row.Name,
+			Prefix:     row.Prefix,
+			Scopes:     strings.Join(row.Scopes, ", "),
+			ExpiresAt:  row.ExpiresAt,
+			LastUsedAt: row.LastUsedAt,
+			CreatedAt:  row.CreatedAt.Time,
+		})
+	}
+	return data, nil
+}
+
+// accessTokens get the current user's personal access tokens page.
+func accessTokens(c *gin.Context) {
+	user := appctx.MustCurrentUser(c)
+
+	data, err := accessTokensData(c, user)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.AccessTokens(data))
+}
+
+// createAccessToken create a personal access token from the form,
+// showing the token once on the page.
+func createAccessToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	data, err := accessTokensData(c, user)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	var request AccessTokenRequest
+	bindErr := c.ShouldBind(&request)
+
+	invalid := func(message string) {
+		data.Name = request.Name
+		data.Selected = request.Scopes
+		data.ExpiresIn = request.ExpiresIn
+		data.Error = message
+		html.Render(c, http.StatusUnprocessableEntity, pages.AccessTokens(data))
+	}
+
+	if bindErr != nil {
+		invalid("please enter a name and choose at least one scope and an expiry")
+		return
+	}
+
+	ttl := time.Duration(request.ExpiresIn) * 24 * time.Hour
+	token, accessToken, err := CreateAccessToken(ctx, queries, user.ID, request.Name, request.Scopes, ttl)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to create the access token")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  user.ID,
+		Action:   audit.ActionTokenCreated,
+		Metadata: map[string]any{"name": accessToken.Name, "prefix": accessToken.Prefix, "scopes": accessToken.Scopes},
+	})
+
+	if data, err = accessTokensData(c, user); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	data.NewToken = token
+
+	html.Render(c, http.StatusOK, pages.AccessTokens(data))
+}
+
+// revokeAccessToken revoke one of the current user's access tokens.
+func revokeAccessToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	var id pgtype.UUID
+	if err := id.Scan(c.Param("id")); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	revoked, err := queries.DeleteAccessTokenByIDAndUserID(ctx, dbx.DeleteAccessTokenByIDAndUserIDParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if revoked > 0 {
+		audit.RecordRequest(c, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionTokenRevoked,
+			Metadata: map[string]any{"id": c.Param("id")},
+		})
+	}
+
+	if hx.IsHTMXRequest() {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Redirect(http.StatusFound, "/auth/tokens")
+}
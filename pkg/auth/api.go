@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/openapi"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// API register and describe the auth JSON endpoints on the versioned API group.
+func API(g *openapi.Group, cfg *config.Config) {
+	limiter := middleware.RateLimit("login")
+	allowJSON := middleware.AllowContentType("application/json")
+	auth := middleware.Authenticated()
+	read := middleware.RequireScope("read")
+	write := middleware.RequireScope("write")
+
+	g.GET("/me", openapi.Operation{
+		ID:       "getCurrentUser",
+		Summary:  "Get the current user and their permissions",
+		Response: CurrentUserResource{},
+	}, auth, read, currentUser)
+	g.POST("/sessions", openapi.Operation{
+		ID:          "login",
+		Summary:     "Log in with an email and password",
+		Description: "Starts a session cookie. When two factor is enabled the user is only logged in once the code is verified with verifyTwoFactor.",
+		Request:     LoginCredentials{},
+		Response:    LoginResource{},
+		Public:      true,
+	}, limiter, allowJSON, login(cfg.Lockout, cfg.RememberMe.Enabled))
+	g.POST("/sessions/2fa", openapi.Operation{
+		ID:          "verifyTwoFactor",
+		Summary:     "Complete a login waiting on two factor",
+		Description: "Verifies a TOTP or recovery code for the login started by the same session cookie, which then logs the user in.",
+		Request:     TwoFactorCode{},
+		Response:    LoginResource{},
+		Public:      true,
+	}, limiter, allowJSON, twoFactor(cfg.Lockout))
+	g.GET("/sessions", openapi.Operation{
+		ID:       "listSessions",
+		Summary:  "List the current user's sessions",
+		Response: []SessionResource{},
+	}, auth, read, listSessions(cfg.Session.Store == config.SessionStorePostgres))
+	g.DELETE("/sessions/:id", openapi.Operation{
+		ID:      "revokeSession",
+		Summary: "Revoke one of the current user's sessions",
+		Status:  http.StatusNoContent,
+	}, auth, write, middleware.NotImpersonating(), revokeSession)
+}
+
+// currentUser get the current user and their permissions.
+func currentUser(c *gin.Context) {
+	user := appctx.MustCurrentUser(c)
+
+	permissions := middleware.Permissions(c)
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	respond.Data(c, http.StatusOK, CurrentUserResource{
+		UserResource: NewUserResource(user),
+		Permissions:  permissions,
+	})
+}
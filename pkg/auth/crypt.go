@@ -1,20 +1,213 @@
 package auth
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gin.go.dev/pkg/config"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// CheckPassword compares a bcrypt hashed password with its possible plaintext equivalent.
+// PasswordHasher hashes passwords into a self describing encoded format.
+type PasswordHasher interface {
+	// Hash the password.
+	Hash(password []byte) ([]byte, error)
+	// Verify compares an encoded hash from this hasher with a possible plaintext equivalent.
+	Verify(hashed, password []byte) bool
+	// Identify checks if the encoded hash was made by this hasher's algorithm.
+	Identify(hashed []byte) bool
+	// Outdated checks if the encoded hash was made with different cost parameters.
+	Outdated(hashed []byte) bool
+}
+
+// passwords the hasher used for new passwords followed by those still verified.
+var passwords = []PasswordHasher{
+	BcryptHasher{Cost: bcrypt.DefaultCost},
+	Argon2idHasher{},
+}
+
+// ConfigurePasswords set the hasher used for new passwords from the config,
+// hashes from the other supported algorithms continue to verify.
+func ConfigurePasswords(c config.PasswordConfig) error {
+	bcryptHasher := BcryptHasher{Cost: c.BcryptCost}
+	if bcryptHasher.Cost == 0 {
+		bcryptHasher.Cost = bcrypt.DefaultCost
+	}
+	argon2idHasher := Argon2idHasher{
+		Memory:      c.Argon2Memory,
+		Iterations:  c.Argon2Iterations,
+		Parallelism: c.Argon2Parallelism,
+	}
+
+	switch c.Algorithm {
+	case config.PasswordAlgorithmBcrypt, "":
+		passwords = []PasswordHasher{bcryptHasher, argon2idHasher}
+	case config.PasswordAlgorithmArgon2id:
+		passwords = []PasswordHasher{argon2idHasher, bcryptHasher}
+	default:
+		return fmt.Errorf("invalid password algorithm '%s'", c.Algorithm)
+	}
+	return nil
+}
+
+// CheckPassword compares a hashed password with its possible plaintext equivalent.
 func CheckPassword(hashedPassword []byte, password []byte) bool {
-	err := bcrypt.CompareHashAndPassword(hashedPassword, password)
-	return err == nil
+	ok, _ := VerifyPassword(hashedPassword, password)
+	return ok
 }
 
-// GeneratePassword generate a bcrypt hashed password from a bytes string.
+// VerifyPassword compares a hashed password with its possible plaintext equivalent,
+// also reporting if it should be rehashed as it uses an outdated algorithm or cost.
+func VerifyPassword(hashedPassword []byte, password []byte) (ok bool, rehash bool) {
+	for i, hasher := range passwords {
+		if hasher.Identify(hashedPassword) {
+			if !hasher.Verify(hashedPassword, password) {
+				return false, false
+			}
+			return true, i > 0 || hasher.Outdated(hashedPassword)
+		}
+	}
+	return false, false
+}
+
+// GeneratePassword generate a hashed password from a bytes string.
 func GeneratePassword(password []byte) ([]byte, error) {
-	hashed, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
-	if err != nil {
+	return passwords[0].Hash(password)
+}
+
+// BcryptHasher hashes passwords with bcrypt,
+// passwords longer than 72 bytes are rejected rather than truncated.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, h.Cost)
+}
+
+func (h BcryptHasher) Verify(hashed, password []byte) bool {
+	return bcrypt.CompareHashAndPassword(hashed, password) == nil
+}
+
+func (h BcryptHasher) Identify(hashed []byte) bool {
+	return bytes.HasPrefix(hashed, []byte("$2a$")) ||
+		bytes.HasPrefix(hashed, []byte("$2b$")) ||
+		bytes.HasPrefix(hashed, []byte("$2y$"))
+}
+
+func (h BcryptHasher) Outdated(hashed []byte) bool {
+	cost, err := bcrypt.Cost(hashed)
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+// Zero parameters use the RFC 9106 second recommended option.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const (
+	argon2idPrefix     = "$argon2id$"
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// params the hasher's cost parameters with defaults applied.
+func (h Argon2idHasher) params() (memory, iterations uint32, parallelism uint8) {
+	memory, iterations, parallelism = h.Memory, h.Iterations, h.Parallelism
+	if memory == 0 {
+		memory = 64 * 1024
+	}
+	if iterations == 0 {
+		iterations = 3
+	}
+	if parallelism == 0 {
+		parallelism = 4
+	}
+	return
+}
+
+func (h Argon2idHasher) Hash(password []byte) ([]byte, error) {
+	memory, iterations, parallelism := h.params()
+
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return nil, err
 	}
-	return hashed, nil
+	key := argon2.IDKey(password, salt, iterations, memory, parallelism, argon2idKeyLength)
+
+	return []byte(fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, memory, iterations, parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+func (h Argon2idHasher) Verify(hashed, password []byte) bool {
+	a, err := decodeArgon2id(hashed)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey(password, a.salt, a.iterations, a.memory, a.parallelism, uint32(len(a.key)))
+	return subtle.ConstantTimeCompare(key, a.key) == 1
+}
+
+func (h Argon2idHasher) Identify(hashed []byte) bool {
+	return bytes.HasPrefix(hashed, []byte(argon2idPrefix))
+}
+
+func (h Argon2idHasher) Outdated(hashed []byte) bool {
+	a, err := decodeArgon2id(hashed)
+	if err != nil {
+		return true
+	}
+	memory, iterations, parallelism := h.params()
+	return a.memory != memory || a.iterations != iterations || a.parallelism != parallelism
+}
+
+// argon2idHash a decoded argon2id PHC string.
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// decodeArgon2id decode an argon2id PHC string.
+func decodeArgon2id(hashed []byte) (a argon2idHash, err error) {
+	parts := bytes.Split(hashed, []byte("$"))
+	if len(parts) != 6 || string(parts[1]) != "argon2id" {
+		return a, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil {
+		return a, err
+	}
+	if version != argon2.Version {
+		return a, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err = fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &a.memory, &a.iterations, &a.parallelism); err != nil {
+		return a, err
+	}
+	if a.salt, err = base64.RawStdEncoding.DecodeString(string(parts[4])); err != nil {
+		return a, err
+	}
+	if a.key, err = base64.RawStdEncoding.DecodeString(string(parts[5])); err != nil {
+		return a, err
+	}
+	if len(a.key) == 0 {
+		return a, errors.New("invalid argon2id hash")
+	}
+	return a, nil
 }
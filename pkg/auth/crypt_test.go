@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+// testArgon2id cheap argon2id parameters so the tests stay fast.
+var testArgon2id = Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// usePasswords set the password hashers for the test, restoring them after.
+func usePasswords(t *testing.T, hashers ...PasswordHasher) {
+	t.Helper()
+
+	previous := passwords
+	passwords = hashers
+	t.Cleanup(func() { passwords = previous })
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hashed, err := testArgon2id.Hash([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := decodeArgon2id(hashed)
+	if err != nil {
+		t.Fatalf("decode %q: %v", hashed, err)
+	}
+	if a.memory != 64 || a.iterations != 1 || a.parallelism != 1 {
+		t.Errorf("decoded params m=%d,t=%d,p=%d, want m=64,t=1,p=1", a.memory, a.iterations, a.parallelism)
+	}
+	if len(a.salt) != argon2idSaltLength || len(a.key) != argon2idKeyLength {
+		t.Errorf("decoded salt %d and key %d bytes, want %d and %d", len(a.salt), len(a.key), argon2idSaltLength, argon2idKeyLength)
+	}
+
+	if !testArgon2id.Identify(hashed) {
+		t.Error("Identify = false, want true")
+	}
+	if !testArgon2id.Verify(hashed, []byte("password")) {
+		t.Error("Verify with the password = false, want true")
+	}
+	if testArgon2id.Verify(hashed, []byte("wrong")) {
+		t.Error("Verify with a wrong password = true, want false")
+	}
+	if testArgon2id.Outdated(hashed) {
+		t.Error("Outdated with the same params = true, want false")
+	}
+	if !(Argon2idHasher{Memory: 128, Iterations: 1, Parallelism: 1}).Outdated(hashed) {
+		t.Error("Outdated with more memory = false, want true")
+	}
+}
+
+func TestDecodeArgon2idInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashed string
+	}{
+		{"empty", ""},
+		{"bcrypt", "$2a$10$abcdefghijklmnopqrstuv"},
+		{"missing key", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ"},
+		{"other version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+		{"bad params", "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+		{"bad salt", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5"},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeArgon2id([]byte(tt.hashed)); err == nil {
+				t.Errorf("decodeArgon2id(%q) err = nil, want an error", tt.hashed)
+			}
+			if testArgon2id.Verify([]byte(tt.hashed), []byte("password")) {
+				t.Errorf("Verify(%q) = true, want false", tt.hashed)
+			}
+		})
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	bcryptHasher := BcryptHasher{Cost: bcrypt.MinCost}
+
+	bcryptHash, err := bcryptHasher.Hash([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2idHash, err := testArgon2id.Hash([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		hashers    []PasswordHasher
+		hashed     []byte
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"current bcrypt", []PasswordHasher{bcryptHasher, testArgon2id}, bcryptHash, "password", true, false},
+		{"bcrypt with another cost", []PasswordHasher{BcryptHasher{Cost: bcrypt.MinCost + 1}, testArgon2id}, bcryptHash, "password", true, true},
+		{"bcrypt after moving to argon2id", []PasswordHasher{testArgon2id, bcryptHasher}, bcryptHash, "password", true, true},
+		{"current argon2id", []PasswordHasher{testArgon2id, bcryptHasher}, argon2idHash, "password", true, false},
+		{"argon2id after moving to bcrypt", []PasswordHasher{bcryptHasher, testArgon2id}, argon2idHash, "password", true, true},
+		{"wrong password", []PasswordHasher{testArgon2id, bcryptHasher}, bcryptHash, "wrong", false, false},
+		{"unknown algorithm", []PasswordHasher{testArgon2id, bcryptHasher}, []byte("plain"), "plain", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePasswords(t, tt.hashers...)
+
+			ok, rehash := VerifyPassword(tt.hashed, []byte(tt.password))
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("VerifyPassword = %v, %v, want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/storage/ratelimit"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/openapi"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The tests that need a database run against TEST_DATABASE_URL and are skipped without it.
+// It is migrated up before the tests, so use a database just for them.
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// testPassword the password of the users created by the tests.
+const testPassword = "password"
+
+// testApp the auth routes served with the same middleware as the server.
+type testApp struct {
+	URL      string
+	Config   *config.Config
+	Postgres *pgxpool.Pool
+	Queries  *dbx.Queries
+}
+
+// newTestApp serve the auth routes against the test database, configure changes the config first.
+func newTestApp(t *testing.T, configure func(cfg *config.Config)) *testApp {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	migrateOnce.Do(func() {
+		migrateErr = migrateTestDatabase(databaseURL)
+	})
+	if migrateErr != nil {
+		t.Fatalf("migrating the test database: %v", migrateErr)
+	}
+
+	pool, err := pgxpool.New(context.Background(), databaseURL)
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	server := httptest.NewUnstartedServer(nil)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Mode:    config.ServerModeTest,
+			BaseURL: "http://" + server.Listener.Addr().String(),
+		},
+		Security: config.SecurityConfig{
+			CsrfSecret: "test_secret",
+			TotpIssuer: "Test",
+		},
+		Session: config.SessionConfig{
+			Store:    config.SessionStoreCookie,
+			Key:      "13d45bf0a822b832cc8886fa41ce4ced30584189bad02ec8ce552ace0d1ae8b1",
+			EncKey:   "2bb61a68ac3dec4f7c25efb062f4ae3b",
+			Path:     "/",
+			MaxAge:   3600,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		Mail: config.MailConfig{
+			Backend: config.MailBackendLog,
+		},
+		RateLimit: config.RateLimitConfig{
+			Backend: config.RateLimitBackendMemory,
+			Limits: map[string]config.RateLimitRule{
+				"login": {Requests: 1000, PeriodSeconds: 60, Key: config.RateLimitKeyIP},
+				"auth":  {Requests: 1000, PeriodSeconds: 60, Key: config.RateLimitKeyIP},
+			},
+		},
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	store := cookie.NewStore(cfg.Session.KeyBytes(), cfg.Session.EncKeyBytes())
+	store.Options(sessions.Options{
+		Path:     cfg.Session.Path,
+		MaxAge:   cfg.Session.MaxAge,
+		HttpOnly: cfg.Session.HttpOnly,
+		SameSite: cfg.Session.SameSite,
+	})
+
+	csrfMiddleware := csrf.Middleware(csrf.Options{
+		Secret: cfg.Security.CsrfSecret,
+		ErrorFunc: func(c *gin.Context) {
+			c.String(http.StatusBadRequest, "CSRF token mismatch")
+			c.Abort()
+		},
+	})
+
+	engine := gin.New()
+	engine.Use(
+		gin.Recovery(),
+		middleware.GinContext(),
+		sessions.Sessions("session", store),
+		middleware.Context(pool),
+		middleware.RateLimits(ratelimit.NewMemoryLimiter(1000), cfg.RateLimit),
+		middleware.RememberMe(cfg.RememberMe, cfg.Session),
+	)
+	engine.HTMLRender = &html.Renderer{Fallback: engine.HTMLRender}
+
+	Router(engine, csrfMiddleware, cfg, &mail.LogSender{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	API(openapi.New("Test", "1.0.0").Group(engine.Group("/api/v1", respond.JSON(), middleware.BearerAuth())), cfg)
+
+	server.Config.Handler = engine
+	server.Start()
+
+	return &testApp{
+		URL:      server.URL,
+		Config:   cfg,
+		Postgres: pool,
+		Queries:  dbx.New(pool),
+	}
+}
+
+// migrateTestDatabase apply all the up migrations to the test database.
+func migrateTestDatabase(databaseURL string) error {
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	driver, err := postgres.WithInstance(conn, &postgres.Config{})
+	if err != nil {
+		return err
+	}
+	fs, err := iofs.New(db.Migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	migrator, err := migrate.NewWithInstance("iofs", fs, "postgres", driver)
+	if err != nil {
+		return err
+	}
+	if err = migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// createUser create an active user with a unique email address and the test password.
+func (a *testApp) createUser(t *testing.T) dbx.AuthUser {
+	t.Helper()
+
+	hashed, err := GeneratePassword([]byte(testPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := a.Queries.CreateUser(context.Background(), dbx.CreateUserParams{
+		Email:          uuid.NewString() + "@example.com",
+		HashedPassword: hashed,
+		FirstName:      "Test",
+		LastName:       "User",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// testClient a browser of the test app, keeping its cookies and not following redirects.
+type testClient struct {
+	t    *testing.T
+	app  *testApp
+	http *http.Client
+}
+
+// client create a new browser of the app without any cookies.
+func (a *testApp) client(t *testing.T) *testClient {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testClient{
+		t:   t,
+		app: a,
+		http: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// do send the request to the app, returning the response with its body read.
+func (c *testClient) do(method, path string, header http.Header, body io.Reader) (*http.Response, string) {
+	c.t.Helper()
+
+	req, err := http.NewRequest(method, c.app.URL+path, body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return res, string(b)
+}
+
+// get the page.
+func (c *testClient) get(path string) (*http.Response, string) {
+	c.t.Helper()
+	return c.do(http.MethodGet, path, nil, nil)
+}
+
+// postForm post the form with the csrf token as an HTMX request, like the pages do.
+func (c *testClient) postForm(path, token string, values url.Values) (*http.Response, string) {
+	c.t.Helper()
+
+	values.Set("_csrf", token)
+	return c.do(http.MethodPost, path, http.Header{
+		"Content-Type": {"application/x-www-form-urlencoded"},
+		"Hx-Request":   {"true"},
+	}, strings.NewReader(values.Encode()))
+}
+
+// postJSON post the value as JSON with the csrf token, decoding the JSON response into out if given.
+// The JSON API needs no csrf token, leave it empty.
+func (c *testClient) postJSON(path, token string, value any, out any) *http.Response {
+	c.t.Helper()
+
+	var body io.Reader
+	if value != nil {
+		b, err := json.Marshal(value)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		body = strings.NewReader(string(b))
+	}
+	res, text := c.do(http.MethodPost, path, http.Header{
+		"Content-Type": {"application/json"},
+		"X-Csrf-Token": {token},
+	}, body)
+	if out != nil && res.StatusCode == http.StatusOK {
+		if err := json.Unmarshal([]byte(text), out); err != nil {
+			c.t.Fatalf("decoding %s response %q: %v", path, text, err)
+		}
+	}
+	return res
+}
+
+// csrfPattern the csrf token in a page, either a hidden input or a data attribute.
+var csrfPattern = regexp.MustCompile(`(?:name="_csrf" value|data-csrf)="([^"]+)"`)
+
+// csrf get the page and the csrf token in it.
+func (c *testClient) csrf(path string) string {
+	c.t.Helper()
+
+	res, body := c.get(path)
+	if res.StatusCode != http.StatusOK {
+		c.t.Fatalf("GET %s: status %d", path, res.StatusCode)
+	}
+	match := csrfPattern.FindStringSubmatch(body)
+	if match == nil {
+		c.t.Fatalf("GET %s: no csrf token", path)
+	}
+	return match[1]
+}
+
+// login with the user's email address and the test password.
+func (c *testClient) login(user dbx.AuthUser) {
+	c.t.Helper()
+
+	token := c.csrf("/auth/login")
+	res, body := c.postForm("/auth/login", token, url.Values{
+		"email":    {user.Email},
+		"password": {testPassword},
+	})
+	if res.StatusCode != http.StatusOK || res.Header.Get("HX-Redirect") != "/" {
+		c.t.Fatalf("login: status %d, redirect %q: %s", res.StatusCode, res.Header.Get("HX-Redirect"), body)
+	}
+}
+
+// loggedIn checks if the client is logged in, by whether a page requiring it is shown.
+func (c *testClient) loggedIn() bool {
+	c.t.Helper()
+
+	res, _ := c.get("/auth/user-menu")
+	return res.StatusCode == http.StatusOK
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+// isLocked checks if the user is locked out of password login.
+func isLocked(ctx context.Context, queries *dbx.Queries, cfg config.LockoutConfig, userID pgtype.UUID) (bool, error) {
+	if !cfg.Enabled {
+		return false, nil
+	}
+	return queries.IsUserLocked(ctx, userID)
+}
+
+// recordFailedLogin count a failed password login,
+// locking the user out once the threshold is reached and returning when until.
+func recordFailedLogin(ctx context.Context, queries *dbx.Queries, cfg config.LockoutConfig, userID pgtype.UUID) (time.Time, error) {
+	if !cfg.Enabled {
+		return time.Time{}, nil
+	}
+
+	lockout, err := queries.RecordFailedLogin(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if int(lockout.FailedAttempts) < cfg.Threshold {
+		return time.Time{}, nil
+	}
+
+	until := time.Now().Add(cfg.Duration(int(lockout.Lockouts)))
+	return until, queries.LockUser(ctx, dbx.LockUserParams{
+		UserID:      userID,
+		LockedUntil: pgtype.Timestamptz{Time: until, Valid: true},
+	})
+}
+
+// clearFailedLogins reset the user's failed logins after a successful login.
+func clearFailedLogins(ctx context.Context, queries *dbx.Queries, cfg config.LockoutConfig, userID pgtype.UUID) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return queries.DeleteLockoutByUserID(ctx, userID)
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MagicLinkRequest used in the magic link validation
+type MagicLinkRequest struct {
+	Email string `form:"email" binding:"required,email"`
+}
+
+// SendMagicLink create a single use sign-in token for the user and email them the link.
+func SendMagicLink(ctx context.Context, queries *dbx.Queries, mailer mail.Sender, baseURL string, lifetime time.Duration, user dbx.AuthUser) error {
+	token, hash, err := GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	if _, err = queries.CreateMagicLink(ctx, dbx.CreateMagicLinkParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(lifetime), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/magic/%s", strings.TrimRight(baseURL, "/"), token)
+	return mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to sign in. It can only be used once and expires in %s.\n\n%s\n\nIf you did not request this you can ignore this email.\n",
+			user.FirstName, lifetime, link,
+		),
+	})
+}
+
+// magicLinkForm get the magic link form
+func magicLinkForm(c *gin.Context) {
+	html.Render(c, http.StatusOK, pages.MagicLink(pages.MagicLinkData{
+		Csrf: csrf.GetToken(c),
+	}))
+}
+
+// sendMagicLink email a sign-in link to the user if they exist.
+// The same response is given whether the account exists or not, and the email is sent
+// in the background so the response time does not tell them apart either.
+func sendMagicLink(mailer mail.Sender, baseURL string, lifetime time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		sent := func() {
+			html.Render(c, http.StatusOK, pages.MagicLink(pages.MagicLinkData{
+				Sent: true,
+			}))
+		}
+
+		var request MagicLinkRequest
+		if err := c.ShouldBind(&request); err != nil {
+			html.Render(c, http.StatusUnprocessableEntity, pages.MagicLink(pages.MagicLinkData{
+				Error: "invalid email address",
+				Csrf:  csrf.GetToken(c),
+			}))
+			return
+		}
+
+		email := strings.ToLower(request.Email)
+		user, err := queries.GetUserByEmail(ctx, email)
+		if err != nil || !user.IsActive {
+			sent()
+			return
+		}
+
+		event := audit.RequestEvent(c, audit.Event{
+			Subject: user.ID,
+			Action:  audit.ActionMagicLinkSent,
+		})
+		sendInBackground(ctx, "magic link", func(ctx context.Context) error {
+			if err := SendMagicLink(ctx, queries, mailer, baseURL, lifetime, user); err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, queries, event); err != nil {
+				slog.ErrorContext(ctx, "magic link audit failed", slog.Any("error", err))
+			}
+			return nil
+		})
+
+		sent()
+	}
+}
+
+// magicLinkLoginForm get the magic link sign in form if the token is valid.
+// Signing in takes a POST so that email link scanners following the link do not use it up.
+func magicLinkLoginForm(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+
+	if _, err := queries.GetMagicLink(ctx, HashToken(c.Param("token"))); err != nil {
+		html.Render(c, http.StatusNotFound, pages.MagicLinkLogin(pages.MagicLinkLoginData{
+			Invalid: true,
+		}))
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.MagicLinkLogin(pages.MagicLinkLoginData{
+		Action: c.Request.URL.Path,
+		Csrf:   csrf.GetToken(c),
+	}))
+}
+
+// magicLinkLogin log the user in using the token then redirect to home,
+// or to the second factor when enabled.
+func magicLinkLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+
+	invalid := func() {
+		html.Render(c, http.StatusUnprocessableEntity, pages.MagicLinkLogin(pages.MagicLinkLoginData{
+			Invalid: true,
+		}))
+	}
+
+	userID, err := queries.UseMagicLink(ctx, HashToken(c.Param("token")))
+	if err != nil {
+		invalid()
+		return
+	}
+
+	user, err := queries.GetUserByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		audit.RecordRequest(c, audit.Event{
+			Subject:  userID,
+			Action:   audit.ActionLoginFailed,
+			Metadata: map[string]any{"reason": "inactive user", "method": "magic link"},
+		})
+		invalid()
+		return
+	}
+
+	if err = queries.DeleteMagicLinksByUserID(ctx, user.ID); err != nil {
+		_ = c.Error(err)
+	}
+
+	redirect := "/"
+	pending := twoFactorEnabled(ctx, queries, user.ID)
+	if pending {
+		setPendingTwoFactor(session, user.ID)
+		redirect = "/auth/2fa/verify"
+	} else {
+		clearPendingTwoFactor(session)
+		session.Set("user_id", user.ID.Bytes)
+	}
+	if err = session.Save(); err != nil {
+		_ = c.Error(err)
+		invalid()
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Actor:    user.ID,
+		Subject:  user.ID,
+		Action:   audit.ActionLogin,
+		Metadata: map[string]any{"method": "magic link", "second_factor_pending": pending},
+	})
+
+	hx.SetRedirect(redirect)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/tokens"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"golang.org/x/oauth2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oidcHTTPTimeout how long to wait for the issuer's discovery document, keys and tokens.
+const oidcHTTPTimeout = 10 * time.Second
+
+// oidcProvider an OpenID Connect identity provider, discovered on first use.
+type oidcProvider struct {
+	config      config.OIDCProviderConfig
+	redirectURL string
+	client      *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// oidcFlow the state of an authorization code flow kept in the session.
+type oidcFlow struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// oidcClaims the id token claims used to find or create the user.
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+// newOIDCProviders create the configured providers keyed by name.
+func newOIDCProviders(c config.OIDCConfig, baseURL string) map[string]*oidcProvider {
+	providers := make(map[string]*oidcProvider, len(c.Providers))
+	for _, p := range c.Providers {
+		providers[p.Name] = &oidcProvider{
+			config:      p,
+			redirectURL: fmt.Sprintf("%s/auth/oidc/%s/callback", strings.TrimRight(baseURL, "/"), p.Name),
+			client:      &http.Client{Timeout: oidcHTTPTimeout},
+		}
+	}
+	return providers
+}
+
+// discover fetch and cache the issuer's discovery document.
+// A background context is used as the provider keeps it to refresh the JWKS.
+func (p *oidcProvider) discover() (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	ctx := oidc.ClientContext(context.Background(), p.client)
+	provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	p.provider = provider
+	return provider, nil
+}
+
+// oauth2 the oauth2 client config for the provider.
+func (p *oidcProvider) oauth2(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.config.Scopes...),
+	}
+}
+
+// oidcLogin redirect to the provider to start the authorization code flow.
+func oidcLogin(providers map[string]*oidcProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := appctx.Session(c)
+
+		p, ok := providers[c.Param("provider")]
+		if !ok {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		provider, err := p.discover()
+		if err != nil {
+			_ = c.AbortWithError(http.StatusBadGateway, err)
+			return
+		}
+
+		flow := oidcFlow{Provider: p.config.Name, Verifier: oauth2.GenerateVerifier()}
+		if flow.State, err = tokens.Random(32); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if flow.Nonce, err = tokens.Random(32); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		b, err := json.Marshal(flow)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		session.Set("oidc_flow", string(b))
+		if err = session.Save(); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		url := p.oauth2(provider).AuthCodeURL(
+			flow.State,
+			oidc.Nonce(flow.Nonce),
+			oauth2.S256ChallengeOption(flow.Verifier),
+		)
+		c.Redirect(http.StatusFound, url)
+	}
+}
+
+// oidcCallback complete the authorization code flow and log the user in.
+func oidcCallback(providers map[string]*oidcProvider, login pages.LoginData) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		postgres := appctx.Postgres(c)
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+
+		invalid := func(err error) {
+			if err != nil {
+				_ = c.Error(err)
+			}
+			data := login
+			data.Error = "unable to sign in with single sign-on"
+			data.Csrf = csrf.GetToken(c)
+			html.Render(c, http.StatusUnauthorized, pages.Login(data))
+		}
+
+		p, ok := providers[c.Param("provider")]
+		if !ok {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		raw, ok := session.Get("oidc_flow").(string)
+		session.Delete("oidc_flow")
+		if !ok {
+			invalid(errors.New("oidc: no flow in progress"))
+			return
+		}
+		var flow oidcFlow
+		if err := json.Unmarshal([]byte(raw), &flow); err != nil {
+			invalid(err)
+			return
+		}
+		if flow.Provider != p.config.Name || c.Query("state") != flow.State {
+			invalid(errors.New("oidc: state mismatch"))
+			return
+		}
+		if e := c.Query("error"); e != "" {
+			invalid(fmt.Errorf("oidc: provider error: %s", e))
+			return
+		}
+
+		provider, err := p.discover()
+		if err != nil {
+			invalid(err)
+			return
+		}
+
+		token, err := p.oauth2(provider).Exchange(oidc.ClientContext(ctx, p.client), c.Query("code"), oauth2.VerifierOption(flow.Verifier))
+		if err != nil {
+			invalid(err)
+			return
+		}
+
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			invalid(errors.New("oidc: no id_token in token response"))
+			return
+		}
+
+		idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+		if err != nil {
+			invalid(err)
+			return
+		}
+		if idToken.Nonce != flow.Nonce {
+			invalid(errors.New("oidc: nonce mismatch"))
+			return
+		}
+
+		var claims oidcClaims
+		if err = idToken.Claims(&claims); err != nil {
+			invalid(err)
+			return
+		}
+
+		user, err := oidcUser(ctx, postgres, queries, p.config, idToken.Subject, claims)
+		if err != nil {
+			invalid(err)
+			return
+		}
+		if !user.IsActive {
+			invalid(nil)
+			return
+		}
+
+		redirect := "/"
+		pending := twoFactorEnabled(ctx, queries, user.ID)
+		if pending {
+			setPendingTwoFactor(session, user.ID)
+			redirect = "/auth/2fa/verify"
+		} else {
+			clearPendingTwoFactor(session)
+			session.Set("user_id", user.ID.Bytes)
+		}
+		if err = session.Save(); err != nil {
+			invalid(err)
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Actor:    user.ID,
+			Subject:  user.ID,
+			Action:   audit.ActionLogin,
+			Metadata: map[string]any{"method": "oidc", "provider": p.config.Name, "second_factor_pending": pending},
+		})
+
+		c.Redirect(http.StatusFound, redirect)
+	}
+}
+
+// oidcUser find the user linked to the provider subject,
+// otherwise link the user with the verified email address,
+// otherwise create the user if the provider allows it.
+func oidcUser(ctx context.Context, postgres *pgxpool.Pool, queries *dbx.Queries, p config.OIDCProviderConfig, subject string, claims oidcClaims) (dbx.AuthUser, error) {
+	user, err := queries.GetUserByIdentity(ctx, dbx.GetUserByIdentityParams{
+		Provider: p.Name,
+		Subject:  subject,
+	})
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return user, err
+	}
+
+	if !claims.EmailVerified || claims.Email == "" {
+		return user, errors.New("oidc: no verified email address")
+	}
+	email := strings.ToLower(claims.Email)
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		return user, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	user, err = qtx.GetUserByEmail(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) && p.AutoCreate {
+		user, err = createOIDCUser(ctx, qtx, email, claims)
+	}
+	if err != nil {
+		return user, err
+	}
+
+	if err = qtx.CreateIdentity(ctx, dbx.CreateIdentityParams{
+		UserID:   user.ID,
+		Provider: p.Name,
+		Subject:  subject,
+	}); err != nil {
+		return user, err
+	}
+
+	return user, tx.Commit(ctx)
+}
+
+// createOIDCUser create a verified user with an unusable random password.
+func createOIDCUser(ctx context.Context, queries *dbx.Queries, email string, claims oidcClaims) (dbx.AuthUser, error) {
+	password, err := tokens.Random(32)
+	if err != nil {
+		return dbx.AuthUser{}, err
+	}
+	hashed, err := GeneratePassword([]byte(password))
+	if err != nil {
+		return dbx.AuthUser{}, err
+	}
+
+	user, err := queries.CreateUser(ctx, dbx.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashed,
+		FirstName:      claims.GivenName,
+		LastName:       claims.FamilyName,
+	})
+	if err != nil {
+		return user, err
+	}
+
+	if err = queries.SetUserVerifiedByID(ctx, user.ID); err != nil {
+		return user, err
+	}
+	user.IsVerified = true
+	return user, nil
+}
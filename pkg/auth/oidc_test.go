@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/google/uuid"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
+)
+
+// fakeIssuer an in-process OpenID Connect provider for the authorization code flow with PKCE.
+// It signs in whoever has the next claims, and signs id tokens with signingKey, its published key by default.
+type fakeIssuer struct {
+	*httptest.Server
+	key        *rsa.PrivateKey
+	signingKey *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims map[string]any
+	codes  map[string]fakeAuthorization
+}
+
+// fakeAuthorization an authorization code waiting to be exchanged.
+type fakeAuthorization struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	claims      map[string]any
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, signingKey: key, codes: map[string]fakeAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("GET /authorize", f.authorize)
+	mux.HandleFunc("POST /token", f.token)
+	mux.HandleFunc("GET /keys", f.keys)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// signIn set the claims of who signs in next, subject "sub" is required.
+func (f *fakeIssuer) signIn(claims map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.claims = claims
+}
+
+// signWith set the key id tokens are signed with.
+func (f *fakeIssuer) signWith(key *rsa.PrivateKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.signingKey = key
+}
+
+func (f *fakeIssuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                f.URL,
+		"authorization_endpoint":                f.URL + "/authorize",
+		"token_endpoint":                        f.URL + "/token",
+		"jwks_uri":                              f.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (f *fakeIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := uuid.NewString()
+	f.mu.Lock()
+	f.codes[code] = fakeAuthorization{
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		claims:      f.claims,
+	}
+	f.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (f *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	f.mu.Lock()
+	authorization, ok := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != authorization.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   f.URL,
+		"aud":   testClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": authorization.nonce,
+	}
+	for k, v := range authorization.claims {
+		claims[k] = v
+	}
+
+	idToken, err := f.sign(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": uuid.NewString(),
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func (f *fakeIssuer) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}},
+	})
+}
+
+// sign the claims as an RS256 JWT.
+func (f *fakeIssuer) sign(claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	f.mu.Lock()
+	key := f.signingKey
+	f.mu.Unlock()
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// oidcLogin sign in through the provider, following the redirects between the app and the issuer,
+// returning the app's final response.
+func (c *testClient) oidcLogin(provider string) *http.Response {
+	c.t.Helper()
+
+	res, _ := c.get("/auth/oidc/" + provider + "/login")
+	if res.StatusCode != http.StatusFound {
+		c.t.Fatalf("login: status %d", res.StatusCode)
+	}
+
+	res, body := c.getURL(res.Header.Get("Location"))
+	if res.StatusCode != http.StatusFound {
+		c.t.Fatalf("authorize: status %d: %s", res.StatusCode, body)
+	}
+
+	res, _ = c.getURL(res.Header.Get("Location"))
+	return res
+}
+
+// getURL get an absolute url, which may not be the app's.
+func (c *testClient) getURL(rawURL string) (*http.Response, string) {
+	c.t.Helper()
+
+	if !strings.HasPrefix(rawURL, c.app.URL) {
+		res, err := c.http.Get(rawURL)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		defer res.Body.Close()
+		return res, ""
+	}
+	return c.get(strings.TrimPrefix(rawURL, c.app.URL))
+}
+
+func TestOIDC(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	app := newTestApp(t, func(cfg *config.Config) {
+		cfg.OIDC = config.OIDCConfig{
+			Enabled: true,
+			Providers: []config.OIDCProviderConfig{{
+				Name:         "test",
+				DisplayName:  "Test",
+				Issuer:       issuer.URL,
+				ClientID:     testClientID,
+				ClientSecret: testClientSecret,
+				Scopes:       []string{"email", "profile"},
+				AutoCreate:   true,
+			}},
+		}
+	})
+	ctx := context.Background()
+
+	t.Run("links the user with the verified email", func(t *testing.T) {
+		user := app.createUser(t)
+		subject := uuid.NewString()
+		issuer.signIn(map[string]any{"sub": subject, "email": user.Email, "email_verified": true})
+
+		client := app.client(t)
+		if res := client.oidcLogin("test"); res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/" {
+			t.Fatalf("callback: status %d, location %q", res.StatusCode, res.Header.Get("Location"))
+		}
+		if !client.loggedIn() {
+			t.Fatal("not logged in after single sign-on")
+		}
+
+		linked, err := app.Queries.GetUserByIdentity(ctx, dbx.GetUserByIdentityParams{Provider: "test", Subject: subject})
+		if err != nil || linked.ID != user.ID {
+			t.Fatalf("identity not linked to the user: %v", err)
+		}
+
+		// once linked the subject signs in even if their email address changes
+		issuer.signIn(map[string]any{"sub": subject, "email": "changed-" + user.Email, "email_verified": true})
+		client = app.client(t)
+		client.oidcLogin("test")
+		if !client.loggedIn() {
+			t.Fatal("linked subject not logged in")
+		}
+	})
+
+	t.Run("creates a verified user", func(t *testing.T) {
+		email := uuid.NewString() + "@example.com"
+		issuer.signIn(map[string]any{"sub": uuid.NewString(), "email": email, "email_verified": true, "given_name": "New", "family_name": "User"})
+
+		client := app.client(t)
+		client.oidcLogin("test")
+		if !client.loggedIn() {
+			t.Fatal("not logged in after single sign-on")
+		}
+
+		user, err := app.Queries.GetUserByEmail(ctx, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.IsVerified || user.FirstName != "New" || user.LastName != "User" {
+			t.Fatalf("unexpected user %+v", user)
+		}
+	})
+
+	t.Run("rejects an unverified email", func(t *testing.T) {
+		user := app.createUser(t)
+		issuer.signIn(map[string]any{"sub": uuid.NewString(), "email": user.Email, "email_verified": false})
+
+		client := app.client(t)
+		if res := client.oidcLogin("test"); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("callback: status %d", res.StatusCode)
+		}
+		if client.loggedIn() {
+			t.Fatal("logged in with an unverified email")
+		}
+	})
+
+	t.Run("rejects an id token signed by another key", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issuer.signWith(key)
+		defer issuer.signWith(issuer.key)
+
+		user := app.createUser(t)
+		issuer.signIn(map[string]any{"sub": uuid.NewString(), "email": user.Email, "email_verified": true})
+
+		client := app.client(t)
+		if res := client.oidcLogin("test"); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("callback: status %d", res.StatusCode)
+		}
+		if client.loggedIn() {
+			t.Fatal("logged in with a forged id token")
+		}
+	})
+
+	t.Run("rejects a mismatched state", func(t *testing.T) {
+		client := app.client(t)
+		client.get("/auth/oidc/test/login")
+		if res, _ := client.get("/auth/oidc/test/callback?code=x&state=wrong"); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("callback: status %d", res.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+)
+
+// NewWebAuthn create a new webauthn relying party from the config.
+func NewWebAuthn(c config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          c.RPID,
+		RPDisplayName: c.RPDisplayName,
+		RPOrigins:     c.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
+
+// PasskeyName used in the passkey registration validation
+type PasskeyName struct {
+	Name string `form:"name" binding:"max=120"`
+}
+
+// passkeyUser adapts a user and their credentials to a webauthn.User.
+type passkeyUser struct {
+	user        dbx.AuthUser
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID.Bytes[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return strings.TrimSpace(u.user.FirstName + " " + u.user.LastName)
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// loadPasskeyUser load the user's stored credentials as a webauthn.User.
+func loadPasskeyUser(ctx context.Context, queries *dbx.Queries, user dbx.AuthUser) (*passkeyUser, error) {
+	rows, err := queries.ListWebAuthnCredentialsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, len(rows))
+	for i, row := range rows {
+		transports := make([]protocol.AuthenticatorTransport, len(row.Transports))
+		for j, t := range row.Transports {
+			transports[j] = protocol.AuthenticatorTransport(t)
+		}
+		credentials[i] = webauthn.Credential{
+			ID:              row.CredentialID,
+			PublicKey:       row.PublicKey,
+			AttestationType: row.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: row.BackupEligible,
+				BackupState:    row.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    row.Aaguid,
+				SignCount: uint32(row.SignCount),
+			},
+		}
+	}
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// setCeremony store the webauthn session data for the ceremony in the session.
+func setCeremony(session sessions.Session, key string, data *webauthn.SessionData) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	session.Set(key, string(b))
+	return session.Save()
+}
+
+// popCeremony get and remove the webauthn session data for the ceremony from the session.
+func popCeremony(session sessions.Session, key string) (webauthn.SessionData, error) {
+	var data webauthn.SessionData
+	raw, ok := session.Get(key).(string)
+	if !ok {
+		return data, errors.New("no webauthn ceremony in progress")
+	}
+	session.Delete(key)
+	if err := session.Save(); err != nil {
+		return data, err
+	}
+	return data, json.Unmarshal([]byte(raw), &data)
+}
+
+// passkeys get the passkeys settings page
+func passkeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	rows, err := queries.ListWebAuthnCredentialsByUserID(ctx, user.ID)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.Passkeys(pages.PasskeysData{
+		Passkeys: rows,
+		Csrf:     csrf.GetToken(c),
+	}))
+}
+
+// beginPasskeyRegistration start registering a new passkey for the current user.
+func beginPasskeyRegistration(wa *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+		user := appctx.MustCurrentUser(c)
+
+		pu, err := loadPasskeyUser(ctx, queries, user)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		exclusions := make([]protocol.CredentialDescriptor, len(pu.credentials))
+		for i, credential := range pu.credentials {
+			exclusions[i] = credential.Descriptor()
+		}
+
+		creation, data, err := wa.BeginRegistration(pu, webauthn.WithExclusions(exclusions))
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		if err = setCeremony(session, "webauthn_registration", data); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, creation)
+	}
+}
+
+// finishPasskeyRegistration verify and store the new passkey for the current user.
+func finishPasskeyRegistration(wa *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+		user := appctx.MustCurrentUser(c)
+
+		var name PasskeyName
+		if err := c.ShouldBindQuery(&name); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid passkey name"})
+			return
+		}
+		if name.Name == "" {
+			name.Name = "Passkey"
+		}
+
+		data, err := popCeremony(session, "webauthn_registration")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no passkey registration in progress"})
+			return
+		}
+
+		pu, err := loadPasskeyUser(ctx, queries, user)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		credential, err := wa.FinishRegistration(pu, data, c.Request)
+		if err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unable to register passkey"})
+			return
+		}
+
+		transports := make([]string, len(credential.Transport))
+		for i, t := range credential.Transport {
+			transports[i] = string(t)
+		}
+
+		if _, err = queries.CreateWebAuthnCredential(ctx, dbx.CreateWebAuthnCredentialParams{
+			UserID:          user.ID,
+			CredentialID:    credential.ID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Aaguid:          credential.Authenticator.AAGUID,
+			SignCount:       int64(credential.Authenticator.SignCount),
+			Transports:      transports,
+			BackupEligible:  credential.Flags.BackupEligible,
+			BackupState:     credential.Flags.BackupState,
+			Name:            name.Name,
+		}); err != nil {
+			if db.IsUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "passkey already registered"})
+				return
+			}
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"redirect": "/auth/passkeys"})
+	}
+}
+
+// deletePasskey remove one of the current user's passkeys.
+func deletePasskey(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	var id pgtype.UUID
+	if err := id.Scan(c.Param("id")); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if err := queries.DeleteWebAuthnCredential(ctx, dbx.DeleteWebAuthnCredentialParams{
+		ID:     id,
+		UserID: user.ID,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/auth/passkeys")
+}
+
+// beginPasskeyLogin start a discoverable passkey login.
+func beginPasskeyLogin(wa *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := appctx.Session(c)
+
+		assertion, data, err := wa.BeginDiscoverableLogin()
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		if err = setCeremony(session, "webauthn_login", data); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, assertion)
+	}
+}
+
+// finishPasskeyLogin verify the passkey assertion and log the user in.
+// User verification is required by the authenticator so no second factor is asked for.
+func finishPasskeyLogin(wa *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+
+		invalid := func() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unable to sign in with this passkey"})
+		}
+
+		data, err := popCeremony(session, "webauthn_login")
+		if err != nil {
+			invalid()
+			return
+		}
+
+		var user dbx.AuthUser
+		handler := func(_, userHandle []byte) (webauthn.User, error) {
+			var id pgtype.UUID
+			if len(userHandle) != len(id.Bytes) {
+				return nil, errors.New("invalid user handle")
+			}
+			copy(id.Bytes[:], userHandle)
+			id.Valid = true
+
+			u, err := queries.GetUserByID(ctx, id)
+			if err != nil || !u.IsActive {
+				return nil, errors.New("user not found")
+			}
+			user = u
+			return loadPasskeyUser(ctx, queries, u)
+		}
+
+		credential, err := wa.FinishDiscoverableLogin(handler, data, c.Request)
+		if err != nil || credential.Authenticator.CloneWarning {
+			if err != nil {
+				_ = c.Error(err)
+			}
+			invalid()
+			return
+		}
+
+		if err = queries.UpdateWebAuthnCredentialUse(ctx, dbx.UpdateWebAuthnCredentialUseParams{
+			CredentialID: credential.ID,
+			SignCount:    int64(credential.Authenticator.SignCount),
+			BackupState:  credential.Flags.BackupState,
+		}); err != nil {
+			_ = c.Error(err)
+			invalid()
+			return
+		}
+
+		session.Clear()
+		session.Set("user_id", user.ID.Bytes)
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+			invalid()
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Actor:    user.ID,
+			Subject:  user.ID,
+			Action:   audit.ActionLogin,
+			Metadata: map[string]any{"method": "passkey"},
+		})
+
+		c.JSON(http.StatusOK, gin.H{"redirect": "/"})
+	}
+}
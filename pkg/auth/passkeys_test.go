@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"gin.go.dev/pkg/config"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"net/http"
+	"testing"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost"
+)
+
+// virtualAuthenticator a software passkey authenticator holding a single P-256 credential.
+// It always reports user presence and verification, and "none" attestation.
+type virtualAuthenticator struct {
+	rpID         string
+	origin       string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newVirtualAuthenticator(t *testing.T, rpID, origin string) *virtualAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err = rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+	return &virtualAuthenticator{rpID: rpID, origin: origin, key: key, credentialID: credentialID}
+}
+
+// credentialJSON the credential as passkeys.js posts it, with the binary fields base64url encoded.
+type credentialJSON struct {
+	ID       string         `json:"id"`
+	RawID    string         `json:"rawId"`
+	Type     string         `json:"type"`
+	Response map[string]any `json:"response"`
+}
+
+// create a new credential for the creation options, as navigator.credentials.create.
+func (a *virtualAuthenticator) create(t *testing.T, options protocol.CredentialCreation) credentialJSON {
+	t.Helper()
+
+	userID, ok := options.Response.User.ID.(string)
+	if !ok {
+		t.Fatalf("unexpected user id %v", options.Response.User.ID)
+	}
+	userHandle, err := base64.RawURLEncoding.DecodeString(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.userHandle = userHandle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authData := a.authenticatorData(byte(protocol.FlagAttestedCredentialData))
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(struct {
+		Format    string         `cbor:"fmt"`
+		Statement map[string]any `cbor:"attStmt"`
+		AuthData  []byte         `cbor:"authData"`
+	}{
+		Format:    "none",
+		Statement: map[string]any{},
+		AuthData:  authData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    a.clientData(t, protocol.CreateCeremony, options.Response.Challenge),
+		"attestationObject": base64.RawURLEncoding.EncodeToString(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get an assertion for the request options, as navigator.credentials.get.
+func (a *virtualAuthenticator) get(t *testing.T, options protocol.CredentialAssertion) credentialJSON {
+	t.Helper()
+
+	a.signCount++
+	authData := a.authenticatorData(0)
+	clientData := a.clientData(t, protocol.AssertCeremony, options.Response.Challenge)
+
+	rawClientData, err := base64.RawURLEncoding.DecodeString(clientData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	digest := sha256.Sum256(append(bytes.Clone(authData), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    clientData,
+		"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+		"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+	})
+}
+
+// authenticatorData the rp id hash, flags with user presence and verification, and sign count.
+func (a *virtualAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags|byte(protocol.FlagUserPresent)|byte(protocol.FlagUserVerified))
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+// clientData the base64url encoded client data JSON for the ceremony.
+func (a *virtualAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) string {
+	t.Helper()
+
+	b, err := json.Marshal(protocol.CollectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *virtualAuthenticator) credential(response map[string]any) credentialJSON {
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	return credentialJSON{ID: id, RawID: id, Type: "public-key", Response: response}
+}
+
+func TestPasskeys(t *testing.T) {
+	app := newTestApp(t, func(cfg *config.Config) {
+		cfg.WebAuthn = config.WebAuthnConfig{
+			Enabled:       true,
+			RPID:          testRPID,
+			RPDisplayName: "Test",
+			RPOrigins:     []string{testOrigin},
+		}
+	})
+	user := app.createUser(t)
+	authenticator := newVirtualAuthenticator(t, testRPID, testOrigin)
+
+	t.Run("register", func(t *testing.T) {
+		client := app.client(t)
+		client.login(user)
+		token := client.csrf("/auth/passkeys")
+
+		var creation protocol.CredentialCreation
+		if res := client.postJSON("/auth/passkeys/register/begin", token, nil, &creation); res.StatusCode != http.StatusOK {
+			t.Fatalf("begin: status %d", res.StatusCode)
+		}
+
+		var finished struct{ Redirect string }
+		res := client.postJSON("/auth/passkeys/register/finish?name=Laptop", token, authenticator.create(t, creation), &finished)
+		if res.StatusCode != http.StatusOK || finished.Redirect != "/auth/passkeys" {
+			t.Fatalf("finish: status %d, redirect %q", res.StatusCode, finished.Redirect)
+		}
+
+		rows, err := app.Queries.ListWebAuthnCredentialsByUserID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0].Name != "Laptop" || !bytes.Equal(rows[0].CredentialID, authenticator.credentialID) {
+			t.Fatalf("unexpected credentials %+v", rows)
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		client := app.client(t)
+		token := client.csrf("/auth/login")
+
+		var assertion protocol.CredentialAssertion
+		if res := client.postJSON("/auth/passkeys/login/begin", token, nil, &assertion); res.StatusCode != http.StatusOK {
+			t.Fatalf("begin: status %d", res.StatusCode)
+		}
+
+		var finished struct{ Redirect string }
+		res := client.postJSON("/auth/passkeys/login/finish", token, authenticator.get(t, assertion), &finished)
+		if res.StatusCode != http.StatusOK || finished.Redirect != "/" {
+			t.Fatalf("finish: status %d, redirect %q", res.StatusCode, finished.Redirect)
+		}
+		if !client.loggedIn() {
+			t.Fatal("not logged in after passkey login")
+		}
+	})
+
+	t.Run("login with another key", func(t *testing.T) {
+		client := app.client(t)
+		token := client.csrf("/auth/login")
+
+		var assertion protocol.CredentialAssertion
+		client.postJSON("/auth/passkeys/login/begin", token, nil, &assertion)
+
+		impostor := newVirtualAuthenticator(t, testRPID, testOrigin)
+		impostor.credentialID = authenticator.credentialID
+		impostor.userHandle = authenticator.userHandle
+
+		if res := client.postJSON("/auth/passkeys/login/finish", token, impostor.get(t, assertion), nil); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("finish: status %d", res.StatusCode)
+		}
+		if client.loggedIn() {
+			t.Fatal("logged in with the wrong key")
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// inviteTTL how long a registration invite is valid for.
+const inviteTTL = 7 * 24 * time.Hour
+
+// RegisterDetails used in the register validation
+type RegisterDetails struct {
+	FirstName string `form:"first_name" binding:"required,max=120"`
+	LastName  string `form:"last_name" binding:"required,max=120"`
+	Email     string `form:"email" binding:"required,email,max=320"`
+	Password  string `form:"password" binding:"required,min=6"`
+	Confirm   string `form:"confirm" binding:"required,eqfield=Password"`
+	Invite    string `form:"invite"`
+}
+
+// SendInvite create a registration invite for the email address and email them the link.
+func SendInvite(ctx context.Context, queries *dbx.Queries, mailer mail.Sender, baseURL string, email string) error {
+	token, hash, err := GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(email)
+	if _, err = queries.CreateInvite(ctx, dbx.CreateInviteParams{
+		Email:     email,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(inviteTTL), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/register?invite=%s", strings.TrimRight(baseURL, "/"), url.QueryEscape(token))
+	return mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "You have been invited",
+		Body: fmt.Sprintf(
+			"Hi,\n\nYou have been invited to create an account. Use the link below to register. It expires in %s.\n\n%s\n",
+			inviteTTL, link,
+		),
+	})
+}
+
+// registerForm get the register form,
+// in invite only mode a valid registration or organization invite is required to show the form.
+func registerForm(cfg config.RegistrationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		data := pages.RegisterData{
+			Invite: c.Query("invite"),
+			Csrf:   csrf.GetToken(c),
+		}
+
+		if data.Invite != "" {
+			hash := HashToken(data.Invite)
+			if invite, err := queries.GetInvite(ctx, hash); err == nil {
+				data.Email = invite.Email
+			} else if invite, err := queries.GetOrganizationInvite(ctx, hash); err == nil {
+				data.Email = invite.Email
+			} else {
+				data.Invite = ""
+			}
+		}
+
+		if cfg.InviteOnly && data.Invite == "" {
+			html.Render(c, http.StatusForbidden, pages.Register(pages.RegisterData{
+				InviteRequired: true,
+			}))
+			return
+		}
+
+		html.Render(c, http.StatusOK, pages.Register(data))
+	}
+}
+
+// register create a new user from the register form, log them in then redirect to home
+func register(cfg config.RegistrationConfig, mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		hx := appctx.HTMX(c)
+		postgres := appctx.Postgres(c)
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+
+		var details RegisterDetails
+		bindErr := c.ShouldBind(&details)
+
+		email := strings.ToLower(details.Email)
+		invalid := func(message string) {
+			html.Render(c, http.StatusUnprocessableEntity, pages.Register(pages.RegisterData{
+				FirstName: details.FirstName,
+				LastName:  details.LastName,
+				Email:     email,
+				Invite:    details.Invite,
+				Error:     message,
+				Csrf:      csrf.GetToken(c),
+			}))
+		}
+
+		if bindErr != nil {
+			invalid("please enter your name, a valid email address and matching passwords of at least 6 characters")
+			return
+		}
+
+		if !cfg.DomainAllowed(email) {
+			invalid("registration is not available for this email address")
+			return
+		}
+
+		hashed, err := GeneratePassword([]byte(details.Password))
+		if err != nil {
+			_ = c.Error(err)
+			invalid("unable to register")
+			return
+		}
+
+		tx, err := postgres.Begin(ctx)
+		if err != nil {
+			_ = c.Error(err)
+			invalid("unable to register")
+			return
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		// the invite is a registration invite, an organization invite, or both sharing the token.
+		// In invite only mode either allows registering.
+		qtx := queries.WithTx(tx)
+		invited := false
+		var orgInvite dbx.UseOrganizationInviteRow
+		joining := false
+		if details.Invite != "" {
+			hash := HashToken(details.Invite)
+			_, err = qtx.UseInvite(ctx, dbx.UseInviteParams{
+				TokenHash: hash,
+				Email:     email,
+			})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				_ = c.Error(err)
+				invalid("unable to register")
+				return
+			}
+			invited = err == nil
+
+			orgInvite, err = qtx.UseOrganizationInvite(ctx, dbx.UseOrganizationInviteParams{
+				TokenHash: hash,
+				Email:     email,
+			})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				_ = c.Error(err)
+				invalid("unable to register")
+				return
+			}
+			joining = err == nil
+		}
+		if cfg.InviteOnly && !invited && !joining {
+			invalid("a valid invitation for this email address is required")
+			return
+		}
+
+		user, err := qtx.CreateUser(ctx, dbx.CreateUserParams{
+			Email:          email,
+			HashedPassword: hashed,
+			FirstName:      details.FirstName,
+			LastName:       details.LastName,
+		})
+		if err != nil {
+			if db.IsUniqueViolation(err) {
+				invalid("an account with this email address already exists")
+				return
+			}
+			_ = c.Error(err)
+			invalid("unable to register")
+			return
+		}
+
+		if joining {
+			if err = qtx.CreateMembership(ctx, dbx.CreateMembershipParams{
+				OrganizationID: orgInvite.OrganizationID,
+				UserID:         user.ID,
+				Role:           orgInvite.Role,
+			}); err != nil {
+				_ = c.Error(err)
+				invalid("unable to register")
+				return
+			}
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			_ = c.Error(err)
+			invalid("unable to register")
+			return
+		}
+
+		if err = SendVerification(ctx, queries, mailer, baseURL, user); err != nil {
+			_ = c.Error(err)
+		}
+
+		clearPendingTwoFactor(session)
+		session.Set("user_id", user.ID.Bytes)
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+		}
+
+		hx.SetRedirect("/")
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// passwordResetTTL how long a password reset link is valid for.
+const passwordResetTTL = time.Hour
+
+// ForgotPasswordRequest used in the forgot password validation
+type ForgotPasswordRequest struct {
+	Email string `form:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest used in the reset password validation
+type ResetPasswordRequest struct {
+	Password string `form:"password" binding:"required,min=6"`
+	Confirm  string `form:"confirm" binding:"required,eqfield=Password"`
+}
+
+// SendPasswordReset create a password reset token for the user and email them the link.
+func SendPasswordReset(ctx context.Context, queries *dbx.Queries, mailer mail.Sender, baseURL string, user dbx.AuthUser) error {
+	token, hash, err := GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	if _, err = queries.CreatePasswordReset(ctx, dbx.CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(passwordResetTTL), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/reset-password/%s", strings.TrimRight(baseURL, "/"), token)
+	return mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your password. It expires in %s.\n\n%s\n\nIf you did not request this you can ignore this email.\n",
+			user.FirstName, passwordResetTTL, link,
+		),
+	})
+}
+
+// sendInBackground send an email after the request has been responded to, for handlers that
+// must respond the same whether the account exists or not. The request's cancellation is ignored,
+// failures are logged as there is no longer a request to fail.
+func sendInBackground(ctx context.Context, name string, send func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := send(ctx); err != nil {
+			slog.ErrorContext(ctx, name+" email failed", slog.Any("error", err))
+		}
+	}()
+}
+
+// forgotPasswordForm get the forgot password form
+func forgotPasswordForm(c *gin.Context) {
+	html.Render(c, http.StatusOK, pages.ForgotPassword(pages.ForgotPasswordData{
+		Csrf: csrf.GetToken(c),
+	}))
+}
+
+// forgotPassword email a reset link to the user if they exist.
+// The same response is given whether the account exists or not, and the email is sent
+// in the background so the response time does not tell them apart either.
+func forgotPassword(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		sent := func() {
+			html.Render(c, http.StatusOK, pages.ForgotPassword(pages.ForgotPasswordData{
+				Sent: true,
+			}))
+		}
+
+		var request ForgotPasswordRequest
+		if err := c.ShouldBind(&request); err != nil {
+			html.Render(c, http.StatusUnprocessableEntity, pages.ForgotPassword(pages.ForgotPasswordData{
+				Error: "invalid email address",
+				Csrf:  csrf.GetToken(c),
+			}))
+			return
+		}
+
+		email := strings.ToLower(request.Email)
+		user, err := queries.GetUserByEmail(ctx, email)
+		if err != nil || !user.IsActive {
+			sent()
+			return
+		}
+
+		sendInBackground(ctx, "password reset", func(ctx context.Context) error {
+			return SendPasswordReset(ctx, queries, mailer, baseURL, user)
+		})
+
+		sent()
+	}
+}
+
+// resetPasswordForm get the reset password form if the token is valid
+func resetPasswordForm(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+
+	if _, err := queries.GetPasswordReset(ctx, HashToken(c.Param("token"))); err != nil {
+		html.Render(c, http.StatusNotFound, pages.ResetPassword(pages.ResetPasswordData{
+			Invalid: true,
+		}))
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.ResetPassword(pages.ResetPasswordData{
+		Action: c.Request.URL.Path,
+		Csrf:   csrf.GetToken(c),
+	}))
+}
+
+// resetPassword set the new password using the token then redirect to login
+func resetPassword(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+
+	invalid := func(message string) {
+		html.Render(c, http.StatusUnprocessableEntity, pages.ResetPassword(pages.ResetPasswordData{
+			Action: c.Request.URL.Path,
+			Error:  message,
+			Csrf:   csrf.GetToken(c),
+		}))
+	}
+
+	var request ResetPasswordRequest
+	if err := c.ShouldBind(&request); err != nil {
+		invalid("passwords must match and be at least 6 characters")
+		return
+	}
+
+	hashed, err := GeneratePassword([]byte(request.Password))
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	userID, err := qtx.UsePasswordReset(ctx, HashToken(c.Param("token")))
+	if err != nil {
+		invalid("this password reset link is invalid or has expired")
+		return
+	}
+
+	if err = qtx.SetUserPasswordByID(ctx, dbx.SetUserPasswordByIDParams{
+		ID:             userID,
+		HashedPassword: hashed,
+	}); err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	if err = qtx.DeletePasswordResetsByUserID(ctx, userID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	if _, err = qtx.DeleteSessionsByUserID(ctx, userID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	if err = qtx.DeleteRememberTokensByUserID(ctx, userID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.Error(err)
+		invalid("unable to reset password")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Actor:   userID,
+		Subject: userID,
+		Action:  audit.ActionPasswordReset,
+	})
+
+	hx.SetRedirect("/auth/login")
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/google/uuid"
+	"time"
+)
+
+// UserResource the JSON representation of a user.
+type UserResource struct {
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	IsActive   bool      `json:"is_active"`
+	IsVerified bool      `json:"is_verified"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CurrentUserResource the JSON representation of the current user, with what they can do.
+type CurrentUserResource struct {
+	UserResource
+	Permissions []string `json:"permissions"`
+}
+
+// LoginResource the JSON response to logging in, the user is only logged in
+// once the second factor is verified when one is required.
+type LoginResource struct {
+	TwoFactorRequired bool          `json:"two_factor_required"`
+	User              *UserResource `json:"user,omitempty"`
+}
+
+// SessionResource the JSON representation of one of the current user's sessions.
+type SessionResource struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// NewUserResource the JSON representation of the user.
+func NewUserResource(user dbx.AuthUser) UserResource {
+	return UserResource{
+		ID:         uuid.UUID(user.ID.Bytes).String(),
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		IsActive:   user.IsActive,
+		IsVerified: user.IsVerified,
+		CreatedAt:  user.CreatedAt.Time,
+	}
+}
+
+// sessionResources the JSON representation of the sessions list rows.
+func sessionResources(rows []pages.SessionRow) []SessionResource {
+	resources := make([]SessionResource, 0, len(rows))
+	for _, row := range rows {
+		resources = append(resources, SessionResource(row))
+	}
+	return resources
+}
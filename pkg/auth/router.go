@@ -2,16 +2,25 @@ package auth
 
 import (
 	"encoding/gob"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
 	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/storage/ratelimit"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
 	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/respond"
 	"gin.go.dev/pkg/ui/components"
 	"gin.go.dev/pkg/ui/pages"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	csrf "github.com/stuartaccent/gin-csrf"
-	"golang.org/x/time/rate"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func init() {
@@ -20,80 +29,282 @@ func init() {
 
 // LoginCredentials used in the login validation
 type LoginCredentials struct {
-	Email    string `form:"email" binding:"required,email"`
-	Password string `form:"password" binding:"required,min=6"`
+	Email    string `form:"email" json:"email" binding:"required,email"`
+	Password string `form:"password" json:"password" binding:"required,min=6"`
+	Remember bool   `form:"remember" json:"remember"`
 }
 
 // Router create a new Router.
-func Router(e *gin.Engine, csrf gin.HandlerFunc) {
-	limiter := middleware.RateLimiter(rate.Limit(2), 5)
+// The mailer is used to send account emails containing links built from the server base url.
+func Router(e *gin.Engine, csrf gin.HandlerFunc, cfg *config.Config, mailer mail.Sender) {
+	baseURL := cfg.Server.BaseURL
+	loginData := pages.LoginData{
+		Register:   cfg.Registration.Enabled,
+		Passkeys:   cfg.WebAuthn.Enabled,
+		MagicLink:  cfg.MagicLink.Enabled,
+		RememberMe: cfg.RememberMe.Enabled,
+	}
+	if cfg.OIDC.Enabled {
+		for _, p := range cfg.OIDC.Providers {
+			loginData.Providers = append(loginData.Providers, pages.LoginProvider{
+				Name:        p.Name,
+				DisplayName: p.DisplayName,
+			})
+		}
+	}
+	limiter := middleware.RateLimit("auth")
+	loginLimiter := middleware.RateLimit("login")
 	allowForm := middleware.AllowContentType("application/x-www-form-urlencoded")
 	auth := middleware.Authenticated()
+	sensitive := middleware.NotImpersonating()
 	g := e.Group("/auth")
 	{
-		g.GET("/login", csrf, loginForm)
-		g.POST("/login", limiter, allowForm, csrf, login)
+		g.GET("/login", csrf, loginForm(loginData))
+		g.POST("/login", loginLimiter, allowForm, csrf, login(cfg.Lockout, cfg.RememberMe.Enabled))
 		g.GET("/logout", logout)
 		g.GET("/user-menu", auth, userMenu)
+		g.GET("/forgot-password", csrf, forgotPasswordForm)
+		g.POST("/forgot-password", limiter, allowForm, csrf, forgotPassword(mailer, baseURL))
+		g.GET("/reset-password/:token", csrf, resetPasswordForm)
+		g.POST("/reset-password/:token", limiter, sensitive, allowForm, csrf, resetPassword)
+		g.GET("/verify", csrf, resendVerificationForm)
+		g.POST("/verify", limiter, allowForm, csrf, resendVerification(mailer, baseURL))
+		g.GET("/verify/:token", verify)
+	}
+	tk := e.Group("/auth/tokens", auth)
+	{
+		tk.GET("", csrf, accessTokens)
+		tk.POST("", sensitive, allowForm, csrf, createAccessToken)
+		tk.POST("/:id/revoke", sensitive, allowForm, csrf, revokeAccessToken)
+	}
+	sessionsEnabled := cfg.Session.Store == config.SessionStorePostgres
+	ss := e.Group("/auth/sessions", auth)
+	{
+		ss.GET("", csrf, listSessions(sessionsEnabled))
+		if sessionsEnabled {
+			ss.POST("/:id/revoke", sensitive, allowForm, csrf, revokeSession)
+			ss.POST("/revoke-others", sensitive, allowForm, csrf, revokeOtherSessions)
+		}
+	}
+	tf := e.Group("/auth/2fa")
+	{
+		tf.GET("/verify", csrf, twoFactorForm)
+		tf.POST("/verify", loginLimiter, allowForm, csrf, twoFactor(cfg.Lockout))
+		tf.GET("", auth, csrf, twoFactorSettings)
+		tf.GET("/qr.png", auth, sensitive, twoFactorQR(cfg.Security.TotpIssuer))
+		tf.POST("/enable", limiter, auth, sensitive, allowForm, csrf, enableTwoFactor)
+		tf.POST("/disable", limiter, auth, sensitive, allowForm, csrf, disableTwoFactor)
+	}
+	if cfg.WebAuthn.Enabled {
+		wa, err := NewWebAuthn(cfg.WebAuthn)
+		if err != nil {
+			log.Fatalf("Invalid webauthn config: %v", err)
+		}
+		allowJSON := middleware.AllowContentType("application/json")
+		pk := e.Group("/auth/passkeys")
+		{
+			pk.GET("", auth, csrf, passkeys)
+			pk.POST("/register/begin", auth, sensitive, csrf, beginPasskeyRegistration(wa))
+			pk.POST("/register/finish", auth, sensitive, allowJSON, csrf, finishPasskeyRegistration(wa))
+			pk.POST("/:id/delete", auth, sensitive, allowForm, csrf, deletePasskey)
+			pk.POST("/login/begin", loginLimiter, csrf, beginPasskeyLogin(wa))
+			pk.POST("/login/finish", loginLimiter, allowJSON, csrf, finishPasskeyLogin(wa))
+		}
+	}
+	if cfg.OIDC.Enabled {
+		providers := newOIDCProviders(cfg.OIDC, baseURL)
+		g.GET("/oidc/:provider/login", oidcLogin(providers))
+		g.GET("/oidc/:provider/callback", oidcCallback(providers, loginData))
+	}
+	if cfg.MagicLink.Enabled {
+		ipLimit := max(1, cfg.MagicLink.PerIPPerHour)
+		emailLimit := max(1, cfg.MagicLink.PerEmailPerHour)
+		perIP := middleware.RateLimitBy("magic_link_ip", ratelimit.Limit{Requests: ipLimit, Period: time.Hour}, middleware.RateLimitByIP)
+		perEmail := middleware.RateLimitBy("magic_link_email", ratelimit.Limit{Requests: emailLimit, Period: time.Hour}, middleware.RateLimitByEmail)
+		g.GET("/magic", csrf, magicLinkForm)
+		g.POST("/magic", perIP, allowForm, perEmail, csrf, sendMagicLink(mailer, baseURL, cfg.MagicLink.Lifetime()))
+		g.GET("/magic/:token", csrf, magicLinkLoginForm)
+		g.POST("/magic/:token", loginLimiter, allowForm, csrf, magicLinkLogin)
+	}
+	if cfg.Registration.Enabled {
+		g.GET("/register", csrf, registerForm(cfg.Registration))
+		g.POST("/register", limiter, allowForm, csrf, register(cfg.Registration, mailer, baseURL))
 	}
 }
 
 // loginForm get the login form
-func loginForm(c *gin.Context) {
-	session := c.MustGet("session").(sessions.Session)
-	session.Clear()
-	c.HTML(http.StatusOK, "", pages.Login(pages.LoginData{
-		Csrf: csrf.GetToken(c),
-	}))
+func loginForm(data pages.LoginData) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := appctx.Session(c)
+		session.Clear()
+		d := data
+		d.Csrf = csrf.GetToken(c)
+		html.Render(c, http.StatusOK, pages.Login(d))
+	}
 }
 
-// login the user from the login form then redirect to home
-func login(c *gin.Context) {
-	ctx := c.Request.Context()
-	hx := c.MustGet("htmx").(*middleware.HTMX)
-	queries := c.MustGet("queries").(*dbx.Queries)
-	session := c.MustGet("session").(sessions.Session)
-
-	invalid := func() {
-		c.HTML(http.StatusUnprocessableEntity, "", pages.Login(pages.LoginData{
-			Error: "invalid email address or password",
-			Csrf:  csrf.GetToken(c),
-		}))
-	}
+// login the user from the login form then redirect to home,
+// or from JSON credentials responding with the user.
+// Repeated failures lock the account, reported with the same invalid message.
+func login(lockout config.LockoutConfig, rememberMe bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		hx := appctx.HTMX(c)
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
 
-	var credentials LoginCredentials
-	if err := c.ShouldBind(&credentials); err != nil {
-		invalid()
-		return
-	}
+		invalid := func() {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusUnauthorized, "invalid_credentials", "invalid email address or password")
+				return
+			}
+			html.Render(c, http.StatusUnprocessableEntity, pages.Login(pages.LoginData{
+				Error:      "invalid email address or password",
+				Csrf:       csrf.GetToken(c),
+				RememberMe: rememberMe,
+			}))
+		}
 
-	email := strings.ToLower(credentials.Email)
-	user, err := queries.GetUserByEmail(ctx, email)
-	if err != nil || !user.IsActive {
-		invalid()
-		return
-	}
+		var credentials LoginCredentials
+		if err := c.ShouldBind(&credentials); err != nil {
+			invalid()
+			return
+		}
 
-	password := []byte(credentials.Password)
-	if !CheckPassword(user.HashedPassword, password) {
-		invalid()
-		return
-	}
+		email := strings.ToLower(credentials.Email)
+		user, err := queries.GetUserByEmail(ctx, email)
+		if err != nil || !user.IsActive {
+			audit.RecordRequest(c, audit.Event{
+				Subject:  user.ID,
+				Action:   audit.ActionLoginFailed,
+				Metadata: map[string]any{"email": email, "reason": "unknown or inactive user"},
+			})
+			invalid()
+			return
+		}
 
-	session.Set("user_id", user.ID.Bytes)
-	if err = session.Save(); err != nil {
-		_ = c.Error(err)
-		invalid()
-		return
-	}
+		failed := func(reason string) {
+			audit.RecordRequest(c, audit.Event{
+				Subject:  user.ID,
+				Action:   audit.ActionLoginFailed,
+				Metadata: map[string]any{"reason": reason},
+			})
+			invalid()
+		}
+
+		locked, err := isLocked(ctx, queries, lockout, user.ID)
+		if err != nil {
+			_ = c.Error(err)
+		}
+		if err != nil || locked {
+			failed("locked")
+			return
+		}
+
+		password := []byte(credentials.Password)
+		ok, rehash := VerifyPassword(user.HashedPassword, password)
+		if !ok {
+			until, err := recordFailedLogin(ctx, queries, lockout, user.ID)
+			if err != nil {
+				_ = c.Error(err)
+			}
+			if !until.IsZero() {
+				audit.RecordRequest(c, audit.Event{
+					Subject:  user.ID,
+					Action:   audit.ActionLocked,
+					Metadata: map[string]any{"until": until},
+				})
+			}
+			failed("password")
+			return
+		}
+
+		if rehash {
+			if hashed, err := GeneratePassword(password); err != nil {
+				_ = c.Error(err)
+			} else if err = queries.RehashUserPassword(ctx, dbx.RehashUserPasswordParams{
+				ID:                user.ID,
+				OldHashedPassword: user.HashedPassword,
+				NewHashedPassword: hashed,
+			}); err != nil {
+				_ = c.Error(err)
+			}
+		}
+
+		// failures are only cleared once the login is complete, so that the second factor
+		// cannot be guessed across repeated password logins without the account locking.
+		redirect := "/"
+		pending := twoFactorEnabled(ctx, queries, user.ID)
+		if pending {
+			setPendingTwoFactor(session, user.ID)
+			session.Set("pending_remember", credentials.Remember)
+			redirect = "/auth/2fa/verify"
+		} else {
+			if err = clearFailedLogins(ctx, queries, lockout, user.ID); err != nil {
+				_ = c.Error(err)
+			}
+			clearPendingTwoFactor(session)
+			session.Set("user_id", user.ID.Bytes)
+		}
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+			invalid()
+			return
+		}
 
-	hx.SetRedirect("/")
-	c.Status(http.StatusOK)
+		if credentials.Remember && !pending {
+			if err = middleware.Remember(c, user.ID); err != nil {
+				_ = c.Error(err)
+			}
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Actor:    user.ID,
+			Subject:  user.ID,
+			Action:   audit.ActionLogin,
+			Metadata: map[string]any{"method": "password", "second_factor_pending": pending, "remember": credentials.Remember},
+		})
+
+		if respond.WantsJSON(c) {
+			resource := LoginResource{TwoFactorRequired: pending}
+			if !pending {
+				u := NewUserResource(user)
+				resource.User = &u
+			}
+			respond.Data(c, http.StatusOK, resource)
+			return
+		}
+
+		hx.SetRedirect(redirect)
+		c.Status(http.StatusOK)
+	}
 }
 
-// logout the user then redirect to login
+// logout the user then redirect to login, logging out while impersonating is recorded against the admin
+// and also ends the impersonation.
 func logout(c *gin.Context) {
-	session := c.MustGet("session").(sessions.Session)
+	session := appctx.Session(c)
+	if userID, ok := session.Get("user_id").([16]byte); ok {
+		id := pgtype.UUID{Bytes: userID, Valid: true}
+		actor := id
+		adminID, impersonating := session.Get(middleware.ImpersonatorIDKey).([16]byte)
+		if impersonating {
+			actor = pgtype.UUID{Bytes: adminID, Valid: true}
+			audit.RecordRequest(c, audit.Event{
+				Actor:   actor,
+				Subject: id,
+				Action:  audit.ActionImpersonationEnded,
+			})
+		}
+		audit.RecordRequest(c, audit.Event{
+			Actor:   actor,
+			Subject: id,
+			Action:  audit.ActionLogout,
+		})
+	}
+	if err := middleware.Forget(c); err != nil {
+		_ = c.Error(err)
+	}
 	session.Clear()
 	session.Options(sessions.Options{MaxAge: -1})
 	if err := session.Save(); err != nil {
@@ -105,5 +316,5 @@ func logout(c *gin.Context) {
 // userMenu the user menu in the header.
 func userMenu(c *gin.Context) {
 	_, open := c.GetQuery("open")
-	c.HTML(http.StatusOK, "", components.UserMenu(open))
+	html.Render(c, http.StatusOK, components.UserMenu(open, middleware.Permissions(c)))
 }
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"bytes"
+	"gin.go.dev/pkg/storage/db/dbx"
+	sessionstore "gin.go.dev/pkg/storage/session"
+	"gin.go.dev/pkg/tokens"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/respond"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+)
+
+// describeUserAgent a short browser and platform description of a user agent.
+func describeUserAgent(ua string) string {
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := "unknown device"
+	for _, p := range []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	return browser + " on " + platform
+}
+
+// listSessions get the current user's active sessions page, or the sessions as JSON.
+func listSessions(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+		user := appctx.MustCurrentUser(c)
+
+		if !enabled && respond.WantsJSON(c) {
+			respond.Error(c, http.StatusNotFound, "sessions_unavailable", "session management is not available with the current session store")
+			return
+		}
+
+		data := pages.SessionsData{
+			Enabled: enabled,
+		}
+
+		if enabled {
+			rows, err := queries.ListSessionsByUserID(ctx, user.ID)
+			if err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			current := tokens.Hash(session.ID())
+			for _, row := range rows {
+				data.Sessions = append(data.Sessions, pages.SessionRow{
+					ID:         uuid.UUID(row.ID.Bytes).String(),
+					Device:     describeUserAgent(row.UserAgent),
+					IP:         row.CreatedIp,
+					UserAgent:  row.UserAgent,
+					CreatedAt:  row.CreatedAt.Time,
+					LastSeenAt: row.LastSeenAt.Time,
+					Current:    bytes.Equal(row.TokenHash, current),
+				})
+			}
+		}
+
+		html.Negotiate(c, http.StatusOK, sessionResources(data.Sessions), func() templ.Component {
+			data.Csrf = csrf.GetToken(c)
+			return pages.Sessions(data)
+		})
+	}
+}
+
+// revokeSession revoke one of the current user's sessions,
+// HTMX requests get an empty response to remove the row and JSON requests no content.
+func revokeSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	notFound := func() {
+		if respond.WantsJSON(c) {
+			respond.Error(c, http.StatusNotFound, "not_found", "the session does not exist")
+			return
+		}
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+
+	var id pgtype.UUID
+	if err := id.Scan(c.Param("id")); err != nil {
+		notFound()
+		return
+	}
+
+	// the device's remember me token goes first, while the session still links to it
+	if err := queries.DeleteRememberTokenBySessionID(ctx, dbx.DeleteRememberTokenBySessionIDParams{
+		ID:     id,
+		UserID: user.ID,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	revoked, err := queries.DeleteSessionByIDAndUserID(ctx, dbx.DeleteSessionByIDAndUserIDParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	switch {
+	case respond.WantsJSON(c) && revoked == 0:
+		notFound()
+	case respond.WantsJSON(c):
+		c.Status(http.StatusNoContent)
+	case hx.IsHTMXRequest():
+		c.Status(http.StatusOK)
+	default:
+		c.Redirect(http.StatusFound, "/auth/sessions")
+	}
+}
+
+// revokeOtherSessions revoke all the current user's sessions except this one,
+// and the remember me tokens of every other device.
+func revokeOtherSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+	user := appctx.MustCurrentUser(c)
+
+	if _, err := queries.DeleteOtherSessionsByUserID(ctx, dbx.DeleteOtherSessionsByUserIDParams{
+		UserID:    user.ID,
+		TokenHash: tokens.Hash(session.ID()),
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	selector, _ := session.Get(sessionstore.RememberSelectorKey).(string)
+	if err := queries.DeleteOtherRememberTokensByUserID(ctx, dbx.DeleteOtherRememberTokensByUserIDParams{
+		UserID:   user.ID,
+		Selector: selector,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/auth/sessions")
+}
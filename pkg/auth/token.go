@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"gin.go.dev/pkg/tokens"
+)
+
+// GenerateToken generate a random url safe token and its sha256 hash for storage.
+func GenerateToken() (string, []byte, error) {
+	return tokens.Generate()
+}
+
+// HashToken hash a token for storage or lookup.
+func HashToken(token string) []byte {
+	return tokens.Hash(token)
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret the SHA-1 secret of the RFC 6238 test vectors.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	// the RFC 6238 appendix B SHA-1 vectors, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := TOTPCode(rfc6238Secret, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("TOTPCode at %d = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", TOTPCode(rfc6238Secret, step), step, true},
+		{"previous step", TOTPCode(rfc6238Secret, step-1), step - 1, true},
+		{"next step", TOTPCode(rfc6238Secret, step+1), step + 1, true},
+		{"two steps behind", TOTPCode(rfc6238Secret, step-2), 0, false},
+		{"two steps ahead", TOTPCode(rfc6238Secret, step+2), 0, false},
+		{"with spaces", "050 471", step, true},
+		{"too short", "05047", 0, false},
+		{"too long", "0504711", 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v, want %d, %v", tt.code, gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/respond"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/skip2/go-qrcode"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// pendingTwoFactorTTL how long after the password step the code must be entered.
+	pendingTwoFactorTTL = 5 * time.Minute
+	// recoveryCodeCount the number of recovery codes issued when enabling two factor.
+	recoveryCodeCount = 10
+)
+
+// TwoFactorCode used in the two factor code validation
+type TwoFactorCode struct {
+	Code string `form:"code" json:"code" binding:"required,max=32"`
+}
+
+// twoFactorEnabled checks if the user has confirmed two factor.
+func twoFactorEnabled(ctx context.Context, queries *dbx.Queries, userID pgtype.UUID) bool {
+	totp, err := queries.GetTOTPByUserID(ctx, userID)
+	return err == nil && totp.EnabledAt.Valid
+}
+
+// setPendingTwoFactor mark the session as waiting on the second factor for the user.
+func setPendingTwoFactor(session sessions.Session, userID pgtype.UUID) {
+	session.Set("pending_user_id", userID.Bytes)
+	session.Set("pending_at", time.Now().Unix())
+}
+
+// getPendingTwoFactor get the user waiting on the second factor if not expired.
+func getPendingTwoFactor(session sessions.Session) (pgtype.UUID, bool) {
+	userID, ok := session.Get("pending_user_id").([16]byte)
+	if !ok {
+		return pgtype.UUID{}, false
+	}
+	at, ok := session.Get("pending_at").(int64)
+	if !ok || time.Since(time.Unix(at, 0)) > pendingTwoFactorTTL {
+		return pgtype.UUID{}, false
+	}
+	return pgtype.UUID{Bytes: userID, Valid: true}, true
+}
+
+// clearPendingTwoFactor remove any login waiting on the second factor from the session.
+func clearPendingTwoFactor(session sessions.Session) {
+	session.Delete("pending_user_id")
+	session.Delete("pending_at")
+	session.Delete("pending_remember")
+}
+
+// checkSecondFactor checks a totp or recovery code for the user, consuming it if valid.
+func checkSecondFactor(ctx context.Context, queries *dbx.Queries, userID pgtype.UUID, code string) bool {
+	if strings.Contains(code, "-") {
+		_, err := queries.UseRecoveryCode(ctx, dbx.UseRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: HashToken(NormalizeRecoveryCode(code)),
+		})
+		return err == nil
+	}
+	return checkTOTP(ctx, queries, userID, code)
+}
+
+// checkTOTP checks a totp code for the user, recording its time step to prevent replays.
+func checkTOTP(ctx context.Context, queries *dbx.Queries, userID pgtype.UUID, code string) bool {
+	totp, err := queries.GetTOTPByUserID(ctx, userID)
+	if err != nil {
+		return false
+	}
+	step, ok := ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	_, err = queries.UseTOTPStep(ctx, dbx.UseTOTPStepParams{
+		UserID:   userID,
+		LastStep: step,
+	})
+	return err == nil
+}
+
+// twoFactorForm get the two factor code form for a login waiting on it
+func twoFactorForm(c *gin.Context) {
+	session := appctx.Session(c)
+	if _, ok := getPendingTwoFactor(session); !ok {
+		c.Redirect(http.StatusFound, "/auth/login")
+		return
+	}
+	html.Render(c, http.StatusOK, pages.TwoFactor(pages.TwoFactorData{
+		Csrf: csrf.GetToken(c),
+	}))
+}
+
+// twoFactor complete the login using the code then redirect to home,
+// or from a JSON code responding with the user.
+// Wrong codes count towards the same lockout as wrong passwords.
+func twoFactor(lockout config.LockoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hx := appctx.HTMX(c)
+		session := appctx.Session(c)
+
+		invalid := func() {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusUnauthorized, "invalid_second_factor", "invalid authentication code")
+				return
+			}
+			html.Render(c, http.StatusUnprocessableEntity, pages.TwoFactor(pages.TwoFactorData{
+				Error: "invalid authentication code",
+				Csrf:  csrf.GetToken(c),
+			}))
+		}
+
+		userID, ok := getPendingTwoFactor(session)
+		if !ok {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusUnauthorized, "no_pending_login", "log in with an email and password first")
+				return
+			}
+			hx.SetRedirect("/auth/login")
+			c.Status(http.StatusOK)
+			return
+		}
+
+		var request TwoFactorCode
+		if err := c.ShouldBind(&request); err != nil {
+			invalid()
+			return
+		}
+
+		user, err := completeTwoFactor(c, lockout, userID, request.Code)
+		if err != nil {
+			invalid()
+			return
+		}
+
+		remember, _ := session.Get("pending_remember").(bool)
+		clearPendingTwoFactor(session)
+		session.Set("user_id", user.ID.Bytes)
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+			invalid()
+			return
+		}
+
+		if remember {
+			if err = middleware.Remember(c, user.ID); err != nil {
+				_ = c.Error(err)
+			}
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Actor:    user.ID,
+			Subject:  user.ID,
+			Action:   audit.ActionLogin,
+			Metadata: map[string]any{"method": "second factor"},
+		})
+
+		if respond.WantsJSON(c) {
+			u := NewUserResource(user)
+			respond.Data(c, http.StatusOK, LoginResource{User: &u})
+			return
+		}
+
+		hx.SetRedirect("/")
+		c.Status(http.StatusOK)
+	}
+}
+
+// errInvalidSecondFactor the code was wrong, or the user can no longer complete the login.
+var errInvalidSecondFactor = errors.New("invalid second factor")
+
+// completeTwoFactor check the code for the user waiting on the second factor,
+// recording failures and locking the user out once the lockout threshold is reached.
+func completeTwoFactor(c *gin.Context, lockout config.LockoutConfig, userID pgtype.UUID, code string) (dbx.AuthUser, error) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+
+	user, err := queries.GetUserByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		return dbx.AuthUser{}, errInvalidSecondFactor
+	}
+
+	failed := func(reason string) (dbx.AuthUser, error) {
+		audit.RecordRequest(c, audit.Event{
+			Subject:  user.ID,
+			Action:   audit.ActionLoginFailed,
+			Metadata: map[string]any{"reason": reason},
+		})
+		return dbx.AuthUser{}, errInvalidSecondFactor
+	}
+
+	locked, err := isLocked(ctx, queries, lockout, user.ID)
+	if err != nil {
+		_ = c.Error(err)
+	}
+	if err != nil || locked {
+		return failed("locked")
+	}
+
+	if !checkSecondFactor(ctx, queries, user.ID, code) {
+		until, err := recordFailedLogin(ctx, queries, lockout, user.ID)
+		if err != nil {
+			_ = c.Error(err)
+		}
+		if !until.IsZero() {
+			audit.RecordRequest(c, audit.Event{
+				Subject:  user.ID,
+				Action:   audit.ActionLocked,
+				Metadata: map[string]any{"until": until},
+			})
+		}
+		return failed("second factor")
+	}
+
+	if err = clearFailedLogins(ctx, queries, lockout, user.ID); err != nil {
+		_ = c.Error(err)
+	}
+	return user, nil
+}
+
+// twoFactorSettings get the two factor settings page,
+// starting a new enrolment if two factor is not enabled.
+func twoFactorSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	if twoFactorEnabled(ctx, queries, user.ID) {
+		remaining, err := queries.CountRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			_ = c.Error(err)
+		}
+		html.Render(c, http.StatusOK, pages.TwoFactorSettings(pages.TwoFactorSettingsData{
+			Enabled:        true,
+			RemainingCodes: remaining,
+			Csrf:           csrf.GetToken(c),
+		}))
+		return
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = queries.SetPendingTOTP(ctx, dbx.SetPendingTOTPParams{
+		UserID: user.ID,
+		Secret: secret,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.TwoFactorSettings(pages.TwoFactorSettingsData{
+		Secret: EncodeTOTPSecret(secret),
+		Csrf:   csrf.GetToken(c),
+	}))
+}
+
+// twoFactorQR the enrolment QR code for a pending totp secret.
+func twoFactorQR(issuer string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		user := appctx.MustCurrentUser(c)
+
+		totp, err := queries.GetTOTPByUserID(ctx, user.ID)
+		if err != nil || totp.EnabledAt.Valid {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		png, err := qrcode.Encode(TOTPURL(issuer, user.Email, totp.Secret), qrcode.Medium, 256)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.DataFromReader(http.StatusOK, int64(len(png)), "image/png", bytes.NewReader(png), nil)
+	}
+}
+
+// enableTwoFactor confirm the pending totp secret with a code and issue recovery codes.
+func enableTwoFactor(c *gin.Context) {
+	ctx := c.Request.Context()
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	invalid := func(message string) {
+		data := pages.TwoFactorSettingsData{
+			Error: message,
+			Csrf:  csrf.GetToken(c),
+		}
+		if totp, err := queries.GetTOTPByUserID(ctx, user.ID); err == nil {
+			data.Secret = EncodeTOTPSecret(totp.Secret)
+		}
+		html.Render(c, http.StatusUnprocessableEntity, pages.TwoFactorSettings(data))
+	}
+
+	var request TwoFactorCode
+	if err := c.ShouldBind(&request); err != nil {
+		invalid("invalid authentication code")
+		return
+	}
+
+	if twoFactorEnabled(ctx, queries, user.ID) || !checkTOTP(ctx, queries, user.ID, request.Code) {
+		invalid("invalid authentication code")
+		return
+	}
+
+	codes, err := GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to enable two-factor authentication")
+		return
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to enable two-factor authentication")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	if err = qtx.DeleteRecoveryCodesByUserID(ctx, user.ID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to enable two-factor authentication")
+		return
+	}
+	for _, code := range codes {
+		if err = qtx.CreateRecoveryCode(ctx, dbx.CreateRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: HashToken(code),
+		}); err != nil {
+			_ = c.Error(err)
+			invalid("unable to enable two-factor authentication")
+			return
+		}
+	}
+	if err = qtx.EnableTOTP(ctx, user.ID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to enable two-factor authentication")
+		return
+	}
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.Error(err)
+		invalid("unable to enable two-factor authentication")
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.TwoFactorSettings(pages.TwoFactorSettingsData{
+		Enabled:       true,
+		RecoveryCodes: codes,
+	}))
+}
+
+// disableTwoFactor disable two factor after checking a code.
+func disableTwoFactor(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+
+	invalid := func(message string) {
+		remaining, _ := queries.CountRecoveryCodes(ctx, user.ID)
+		html.Render(c, http.StatusUnprocessableEntity, pages.TwoFactorSettings(pages.TwoFactorSettingsData{
+			Enabled:        true,
+			RemainingCodes: remaining,
+			Error:          message,
+			Csrf:           csrf.GetToken(c),
+		}))
+	}
+
+	var request TwoFactorCode
+	if err := c.ShouldBind(&request); err != nil {
+		invalid("invalid authentication code")
+		return
+	}
+
+	if !twoFactorEnabled(ctx, queries, user.ID) || !checkSecondFactor(ctx, queries, user.ID, request.Code) {
+		invalid("invalid authentication code")
+		return
+	}
+
+	if err := DisableTwoFactor(ctx, postgres, queries, user.ID); err != nil {
+		_ = c.Error(err)
+		invalid("unable to disable two-factor authentication")
+		return
+	}
+
+	hx.SetRedirect("/auth/2fa")
+	c.Status(http.StatusOK)
+}
+
+// DisableTwoFactor remove the user's totp secret and recovery codes.
+func DisableTwoFactor(ctx context.Context, db db.TxBeginner, queries *dbx.Queries, userID pgtype.UUID) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	if err = qtx.DeleteTOTPByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err = qtx.DeleteRecoveryCodesByUserID(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
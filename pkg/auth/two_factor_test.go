@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// enableTwoFactor enable totp for the user, returning the secret.
+func (a *testApp) enableTwoFactor(t *testing.T, user dbx.AuthUser) []byte {
+	t.Helper()
+
+	ctx := context.Background()
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Queries.SetPendingTOTP(ctx, dbx.SetPendingTOTPParams{UserID: user.ID, Secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Queries.EnableTOTP(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func TestTwoFactorAPI(t *testing.T) {
+	app := newTestApp(t, nil)
+	user := app.createUser(t)
+	secret := app.enableTwoFactor(t, user)
+	credentials := LoginCredentials{Email: user.Email, Password: testPassword}
+
+	type loginResponse struct{ Data LoginResource }
+
+	t.Run("logs in with the code", func(t *testing.T) {
+		client := app.client(t)
+
+		var started loginResponse
+		if res := client.postJSON("/api/v1/sessions", "", credentials, &started); res.StatusCode != http.StatusOK || !started.Data.TwoFactorRequired {
+			t.Fatalf("login: status %d, %+v", res.StatusCode, started.Data)
+		}
+		if res, _ := client.get("/api/v1/me"); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("me before the code: status %d", res.StatusCode)
+		}
+
+		var finished loginResponse
+		code := TOTPCode(secret, time.Now().Unix()/totpPeriod)
+		if res := client.postJSON("/api/v1/sessions/2fa", "", TwoFactorCode{Code: code}, &finished); res.StatusCode != http.StatusOK || finished.Data.User == nil {
+			t.Fatalf("2fa: status %d, %+v", res.StatusCode, finished.Data)
+		}
+		if res, _ := client.get("/api/v1/me"); res.StatusCode != http.StatusOK {
+			t.Fatalf("me after the code: status %d", res.StatusCode)
+		}
+	})
+
+	t.Run("rejects a wrong code", func(t *testing.T) {
+		client := app.client(t)
+		client.postJSON("/api/v1/sessions", "", credentials, nil)
+
+		if res := client.postJSON("/api/v1/sessions/2fa", "", TwoFactorCode{Code: "000000"}, nil); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("2fa: status %d", res.StatusCode)
+		}
+		if res, _ := client.get("/api/v1/me"); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("me: status %d", res.StatusCode)
+		}
+	})
+
+	t.Run("rejects a code without a pending login", func(t *testing.T) {
+		client := app.client(t)
+
+		code := TOTPCode(secret, time.Now().Unix()/totpPeriod)
+		if res := client.postJSON("/api/v1/sessions/2fa", "", TwoFactorCode{Code: code}, nil); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("2fa: status %d", res.StatusCode)
+		}
+	})
+}
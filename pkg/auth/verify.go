@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// emailVerificationTTL how long an email verification link is valid for.
+const emailVerificationTTL = 72 * time.Hour
+
+// ResendVerificationRequest used in the resend verification validation
+type ResendVerificationRequest struct {
+	Email string `form:"email" binding:"required,email"`
+}
+
+// SendVerification create a verification token for the user and email them the link.
+func SendVerification(ctx context.Context, queries *dbx.Queries, mailer mail.Sender, baseURL string, user dbx.AuthUser) error {
+	token, hash, err := GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	if _, err = queries.CreateEmailVerification(ctx, dbx.CreateEmailVerificationParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(emailVerificationTTL), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify/%s", strings.TrimRight(baseURL, "/"), token)
+	return mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to verify your email address. It expires in %s.\n\n%s\n",
+			user.FirstName, emailVerificationTTL, link,
+		),
+	})
+}
+
+// verify mark the user as verified using the token.
+func verify(c *gin.Context) {
+	ctx := c.Request.Context()
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+
+	invalid := func() {
+		html.Render(c, http.StatusNotFound, pages.Verify(pages.VerifyData{}))
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		invalid()
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	userID, err := qtx.UseEmailVerification(ctx, HashToken(c.Param("token")))
+	if err != nil {
+		invalid()
+		return
+	}
+
+	if err = qtx.SetUserVerifiedByID(ctx, userID); err != nil {
+		_ = c.Error(err)
+		invalid()
+		return
+	}
+
+	if err = qtx.DeleteEmailVerificationsByUserID(ctx, userID); err != nil {
+		_ = c.Error(err)
+		invalid()
+		return
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.Error(err)
+		invalid()
+		return
+	}
+
+	html.Render(c, http.StatusOK, pages.Verify(pages.VerifyData{Verified: true}))
+}
+
+// resendVerificationForm get the resend verification form
+func resendVerificationForm(c *gin.Context) {
+	html.Render(c, http.StatusOK, pages.ResendVerification(pages.ResendVerificationData{
+		Csrf: csrf.GetToken(c),
+	}))
+}
+
+// resendVerification email a new verification link to the user if they exist and are unverified.
+// The same response is given whether the account exists or not, and the email is sent
+// in the background so the response time does not tell them apart either.
+func resendVerification(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		sent := func() {
+			html.Render(c, http.StatusOK, pages.ResendVerification(pages.ResendVerificationData{
+				Sent: true,
+			}))
+		}
+
+		var request ResendVerificationRequest
+		if err := c.ShouldBind(&request); err != nil {
+			html.Render(c, http.StatusUnprocessableEntity, pages.ResendVerification(pages.ResendVerificationData{
+				Error: "invalid email address",
+				Csrf:  csrf.GetToken(c),
+			}))
+			return
+		}
+
+		email := strings.ToLower(request.Email)
+		user, err := queries.GetUserByEmail(ctx, email)
+		if err != nil || !user.IsActive || user.IsVerified {
+			sent()
+			return
+		}
+
+		sendInBackground(ctx, "verification", func(ctx context.Context) error {
+			return SendVerification(ctx, queries, mailer, baseURL, user)
+		})
+
+		sent()
+	}
+}
@@ -10,11 +10,17 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type (
-	SslMode    string
-	ServerMode string
+	SslMode           string
+	ServerMode        string
+	MailBackend       string
+	SessionStore      string
+	PasswordAlgorithm string
+	RateLimitBackend  string
+	RateLimitKey      string
 )
 
 //goland:noinspection GoUnusedConst
@@ -27,6 +33,23 @@ const (
 	ServerModeDebug   ServerMode = "debug"
 	ServerModeRelease ServerMode = "release"
 	ServerModeTest    ServerMode = "test"
+
+	MailBackendLog  MailBackend = "log"
+	MailBackendSmtp MailBackend = "smtp"
+
+	SessionStoreCookie   SessionStore = "cookie"
+	SessionStorePostgres SessionStore = "postgres"
+
+	PasswordAlgorithmBcrypt   PasswordAlgorithm = "bcrypt"
+	PasswordAlgorithmArgon2id PasswordAlgorithm = "argon2id"
+
+	RateLimitBackendMemory   RateLimitBackend = "memory"
+	RateLimitBackendPostgres RateLimitBackend = "postgres"
+
+	RateLimitKeyIP    RateLimitKey = "ip"
+	RateLimitKeyUser  RateLimitKey = "user"
+	RateLimitKeyEmail RateLimitKey = "email"
+	RateLimitKeyRoute RateLimitKey = "route"
 )
 
 // ToGinMode convert string to gin mode
@@ -46,10 +69,19 @@ func (m ServerMode) ToGinMode() string {
 
 // Config represents the top-level configuration structure.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Security SecurityConfig `mapstructure:"security"`
-	Session  SessionConfig  `mapstructure:"session"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Security     SecurityConfig     `mapstructure:"security"`
+	Session      SessionConfig      `mapstructure:"session"`
+	Mail         MailConfig         `mapstructure:"mail"`
+	Registration RegistrationConfig `mapstructure:"registration"`
+	WebAuthn     WebAuthnConfig     `mapstructure:"webauthn"`
+	OIDC         OIDCConfig         `mapstructure:"oidc"`
+	Password     PasswordConfig     `mapstructure:"password"`
+	Lockout      LockoutConfig      `mapstructure:"lockout"`
+	MagicLink    MagicLinkConfig    `mapstructure:"magic_link"`
+	RememberMe   RememberMeConfig   `mapstructure:"remember_me"`
+	RateLimit    RateLimitConfig    `mapstructure:"rate_limit"`
 }
 
 // FromPath creates and validates a new Config from a .toml file.
@@ -76,8 +108,9 @@ func FromPath(path string) (*Config, error) {
 
 // ServerConfig represents the server configuration.
 type ServerConfig struct {
-	Port uint16     `mapstructure:"port"`
-	Mode ServerMode `mapstructure:"mode"`
+	Port    uint16     `mapstructure:"port"`
+	Mode    ServerMode `mapstructure:"mode"`
+	BaseURL string     `mapstructure:"base_url"`
 }
 
 // DatabaseConfig represents the database configuration.
@@ -100,10 +133,12 @@ type SecurityConfig struct {
 	BrowserXSSFilter      bool     `mapstructure:"browser_xss_filter"`
 	ContentSecurityPolicy string   `mapstructure:"content_security_policy"`
 	CsrfSecret            string   `mapstructure:"csrf_secret"`
+	TotpIssuer            string   `mapstructure:"totp_issuer"`
 }
 
 // SessionConfig represents the session configuration.
 type SessionConfig struct {
+	Store    SessionStore  `mapstructure:"store"`
 	Key      string        `mapstructure:"key"`
 	EncKey   string        `mapstructure:"enc_key"`
 	Path     string        `mapstructure:"path"`
@@ -114,6 +149,101 @@ type SessionConfig struct {
 	SameSite http.SameSite `mapstructure:"same_site"`
 }
 
+// MailConfig represents the outgoing mail configuration.
+type MailConfig struct {
+	Backend  MailBackend `mapstructure:"backend"`
+	From     string      `mapstructure:"from"`
+	Host     string      `mapstructure:"host"`
+	Port     uint16      `mapstructure:"port"`
+	User     string      `mapstructure:"user"`
+	Password string      `mapstructure:"password"`
+}
+
+// RegistrationConfig represents the public registration configuration.
+type RegistrationConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	InviteOnly     bool     `mapstructure:"invite_only"`
+}
+
+// WebAuthnConfig represents the passkey relying party configuration.
+type WebAuthnConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	RPID          string   `mapstructure:"rp_id"`
+	RPDisplayName string   `mapstructure:"rp_display_name"`
+	RPOrigins     []string `mapstructure:"rp_origins"`
+}
+
+// OIDCConfig represents the single sign-on configuration.
+type OIDCConfig struct {
+	Enabled   bool                 `mapstructure:"enabled"`
+	Providers []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig represents an OpenID Connect identity provider.
+type OIDCProviderConfig struct {
+	Name         string   `mapstructure:"name"`
+	DisplayName  string   `mapstructure:"display_name"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+	AutoCreate   bool     `mapstructure:"auto_create"`
+}
+
+// PasswordConfig represents the password hashing configuration.
+type PasswordConfig struct {
+	Algorithm         PasswordAlgorithm `mapstructure:"algorithm"`
+	BcryptCost        int               `mapstructure:"bcrypt_cost"`
+	Argon2Memory      uint32            `mapstructure:"argon2_memory"`
+	Argon2Iterations  uint32            `mapstructure:"argon2_iterations"`
+	Argon2Parallelism uint8             `mapstructure:"argon2_parallelism"`
+}
+
+// LockoutConfig represents the failed login lockout configuration.
+// Each lockout lasts twice as long as the previous one, up to the max duration.
+type LockoutConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	Threshold   int  `mapstructure:"threshold"`
+	BaseSeconds int  `mapstructure:"base_seconds"`
+	MaxSeconds  int  `mapstructure:"max_seconds"`
+}
+
+// MagicLinkConfig represents the passwordless email login configuration.
+// Requests for a link are limited per email address and per IP address each hour.
+type MagicLinkConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	LifetimeSeconds int  `mapstructure:"lifetime_seconds"`
+	PerEmailPerHour int  `mapstructure:"per_email_per_hour"`
+	PerIPPerHour    int  `mapstructure:"per_ip_per_hour"`
+}
+
+// RememberMeConfig represents the "keep me signed in" configuration.
+// The remember me cookie outlives the session, logging the user back in when the session expires.
+type RememberMeConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	CookieName string `mapstructure:"cookie_name"`
+	MaxAge     int    `mapstructure:"max_age"`
+}
+
+// RateLimitConfig represents the rate limiting configuration.
+// The memory backend is per server keeping at most max keys, the postgres backend is shared by every server.
+type RateLimitConfig struct {
+	Backend RateLimitBackend         `mapstructure:"backend"`
+	MaxKeys int                      `mapstructure:"max_keys"`
+	Limits  map[string]RateLimitRule `mapstructure:"limits"`
+}
+
+// RateLimitRule represents a named limit, allowing a number of requests each period per key.
+type RateLimitRule struct {
+	Requests      int          `mapstructure:"requests"`
+	PeriodSeconds int          `mapstructure:"period_seconds"`
+	Key           RateLimitKey `mapstructure:"key"`
+}
+
+// defaultRateLimitRule the limit used for a name missing from the config.
+var defaultRateLimitRule = RateLimitRule{Requests: 10, PeriodSeconds: 60, Key: RateLimitKeyIP}
+
 // URL returns the database URL.
 func (c DatabaseConfig) URL() *url.URL {
 	query := url.Values{}
@@ -127,6 +257,61 @@ func (c DatabaseConfig) URL() *url.URL {
 	}
 }
 
+// Addr returns the smtp server address.
+func (c MailConfig) Addr() string {
+	return net.JoinHostPort(c.Host, fmt.Sprintf("%d", c.Port))
+}
+
+// DomainAllowed checks if the email address domain may register.
+// An empty list of allowed domains allows all.
+func (c RegistrationConfig) DomainAllowed(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	_, domain, found := strings.Cut(email, "@")
+	if !found {
+		return false
+	}
+	for _, allowed := range c.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration returns how long to lock an account that has already been locked n times.
+func (c LockoutConfig) Duration(n int) time.Duration {
+	d := time.Duration(c.BaseSeconds) * time.Second
+	limit := time.Duration(c.MaxSeconds) * time.Second
+	for i := 0; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Lifetime returns how long a magic link is valid for.
+func (c MagicLinkConfig) Lifetime() time.Duration {
+	return time.Duration(c.LifetimeSeconds) * time.Second
+}
+
+// Rule returns the named limit, or the default of 10 requests a minute per IP address if it is not configured.
+func (c RateLimitConfig) Rule(name string) RateLimitRule {
+	rule, ok := c.Limits[name]
+	if !ok || rule.Requests <= 0 || rule.PeriodSeconds <= 0 {
+		return defaultRateLimitRule
+	}
+	if rule.Key == "" {
+		rule.Key = RateLimitKeyIP
+	}
+	return rule
+}
+
+// Period returns the period the requests are allowed in.
+func (r RateLimitRule) Period() time.Duration {
+	return time.Duration(r.PeriodSeconds) * time.Second
+}
+
 // KeyBytes returns the session key as a byte array.
 // The key is expected to be a 32 or 64 character hexadecimal string.
 func (c SessionConfig) KeyBytes() (result []byte) {
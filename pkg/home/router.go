@@ -1,6 +1,7 @@
 package home
 
 import (
+	"gin.go.dev/pkg/transport/html"
 	"gin.go.dev/pkg/transport/middleware"
 	"gin.go.dev/pkg/ui/pages"
 	"github.com/gin-gonic/gin"
@@ -18,5 +19,5 @@ func Router(e *gin.Engine) {
 
 // index root page endpoint.
 func index(c *gin.Context) {
-	c.HTML(http.StatusOK, "", pages.Home())
+	html.Render(c, http.StatusOK, pages.Home())
 }
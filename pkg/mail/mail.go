@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/config"
+	"log/slog"
+	"net/smtp"
+	"strings"
+)
+
+// Message an outgoing plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outgoing email messages.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// New create a new Sender from the mail config.
+func New(c config.MailConfig) (Sender, error) {
+	switch c.Backend {
+	case config.MailBackendLog, "":
+		return &LogSender{Logger: slog.Default()}, nil
+	case config.MailBackendSmtp:
+		return &SmtpSender{Config: c}, nil
+	default:
+		return nil, fmt.Errorf("invalid mail backend '%s'", c.Backend)
+	}
+}
+
+// LogSender writes messages to the log instead of sending them, useful for development.
+type LogSender struct {
+	Logger *slog.Logger
+}
+
+// Send log the message.
+func (s *LogSender) Send(ctx context.Context, m Message) error {
+	s.Logger.InfoContext(ctx, "mail",
+		slog.String("to", m.To),
+		slog.String("subject", m.Subject),
+		slog.String("body", m.Body),
+	)
+	return nil
+}
+
+// SmtpSender sends messages through an smtp server.
+type SmtpSender struct {
+	Config config.MailConfig
+}
+
+// Send the message via smtp.
+func (s *SmtpSender) Send(_ context.Context, m Message) error {
+	var auth smtp.Auth
+	if s.Config.User != "" {
+		auth = smtp.PlainAuth("", s.Config.User, s.Config.Password, s.Config.Host)
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + s.Config.From + "\r\n")
+	b.WriteString("To: " + m.To + "\r\n")
+	b.WriteString("Subject: " + m.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.Body)
+
+	return smtp.SendMail(s.Config.Addr(), auth, s.Config.From, []string{m.To}, []byte(b.String()))
+}
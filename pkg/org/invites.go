@@ -0,0 +1,250 @@
+package org
+
+import (
+	"context"
+	"fmt"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/auth"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// inviteTTL how long an organization invite is valid for.
+const inviteTTL = 7 * 24 * time.Hour
+
+// InviteRequest used in the invite member validation
+type InviteRequest struct {
+	Email string `form:"email" binding:"required,email,max=320"`
+	Role  string `form:"role" binding:"required,oneof=owner admin member"`
+}
+
+// SendInvite create an invite to join the organization for the email address and email them the link.
+// The invite also allows registering when there is no account for the email address, even when it is invite only.
+func SendInvite(ctx context.Context, queries *dbx.Queries, mailer mail.Sender, baseURL string, org dbx.Organization, email string, role string, invitedBy pgtype.UUID) error {
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(email)
+	if _, err = queries.CreateOrganizationInvite(ctx, dbx.CreateOrganizationInviteParams{
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hash,
+		InvitedBy:      invitedBy,
+		ExpiresAt:      pgtype.Timestamptz{Time: time.Now().Add(inviteTTL), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/orgs/join/%s", strings.TrimRight(baseURL, "/"), token)
+	return mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "You have been invited to join " + org.Name,
+		Body: fmt.Sprintf(
+			"Hi,\n\nYou have been invited to join %s as %s. Use the link below to accept. It expires in %s.\n\n%s\n\nIf you were not expecting this you can ignore this email.\n",
+			org.Name, role, inviteTTL, link,
+		),
+	})
+}
+
+// createInvite invite an email address to join the current organization,
+// only owners may invite owners.
+func createInvite(mailer mail.Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		user := appctx.MustCurrentUser(c)
+		org := appctx.MustOrg(c)
+
+		var request InviteRequest
+		if err := c.ShouldBind(&request); err != nil {
+			renderMembers(c, http.StatusUnprocessableEntity, "", "please enter a valid email address and role")
+			return
+		}
+
+		if request.Role == RoleOwner && appctx.OrgRole(c) != RoleOwner {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		email := strings.ToLower(request.Email)
+		if err := SendInvite(ctx, queries, mailer, baseURL, org, email, request.Role, user.ID); err != nil {
+			_ = c.Error(err)
+			renderMembers(c, http.StatusUnprocessableEntity, "", "unable to send the invite")
+			return
+		}
+
+		audit.RecordRequest(c, audit.Event{
+			Action:   audit.ActionOrgMemberInvited,
+			Metadata: map[string]any{"org_id": uuid.UUID(org.ID.Bytes).String(), "email": email, "role": request.Role},
+		})
+
+		renderMembers(c, http.StatusOK, "Invite sent to "+email+".", "")
+	}
+}
+
+// revokeInvite revoke an invite of the current organization.
+func revokeInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	org := appctx.MustOrg(c)
+
+	var id pgtype.UUID
+	if err := id.Scan(c.Param("id")); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	revoked, err := queries.DeleteOrganizationInvite(ctx, dbx.DeleteOrganizationInviteParams{
+		ID:             id,
+		OrganizationID: org.ID,
+	})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if revoked > 0 {
+		audit.RecordRequest(c, audit.Event{
+			Action:   audit.ActionOrgInviteRevoked,
+			Metadata: map[string]any{"org_id": uuid.UUID(org.ID.Bytes).String(), "id": c.Param("id")},
+		})
+	}
+
+	renderMembers(c, http.StatusOK, "", "")
+}
+
+// joinForm get the join organization form if the invite is valid,
+// asking to log in first or to register when the invited email address has no account.
+func joinForm(register string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		token := c.Param("token")
+
+		invite, err := queries.GetOrganizationInvite(ctx, auth.HashToken(token))
+		if err != nil {
+			html.Render(c, http.StatusNotFound, pages.OrgJoin(pages.OrgJoinData{
+				Invalid: true,
+			}))
+			return
+		}
+
+		data := pages.OrgJoinData{
+			Action:  c.Request.URL.Path,
+			OrgName: invite.OrganizationName,
+			Email:   invite.Email,
+			Role:    invite.Role,
+			Csrf:    csrf.GetToken(c),
+		}
+
+		user, ok := middleware.CurrentUser(c)
+		if ok {
+			data.LoggedInAs = user.Email
+		} else if _, err = queries.GetUserByEmail(ctx, invite.Email); err != nil && register != "" {
+			data.Register = register + "?invite=" + url.QueryEscape(token)
+		}
+
+		html.Render(c, http.StatusOK, pages.OrgJoin(data))
+	}
+}
+
+// join accept the invite as the logged-in user, make the organization the current one
+// then redirect to home. The invite must be for the user's email address.
+func join(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+	user := appctx.MustCurrentUser(c)
+	hash := auth.HashToken(c.Param("token"))
+
+	invite, err := queries.GetOrganizationInvite(ctx, hash)
+	if err != nil {
+		html.Render(c, http.StatusUnprocessableEntity, pages.OrgJoin(pages.OrgJoinData{
+			Invalid: true,
+		}))
+		return
+	}
+
+	invalid := func(message string) {
+		html.Render(c, http.StatusUnprocessableEntity, pages.OrgJoin(pages.OrgJoinData{
+			Action:     c.Request.URL.Path,
+			OrgName:    invite.OrganizationName,
+			Email:      invite.Email,
+			Role:       invite.Role,
+			LoggedInAs: user.Email,
+			Error:      message,
+			Csrf:       csrf.GetToken(c),
+		}))
+	}
+
+	if invite.Email != user.Email {
+		invalid("this invitation was sent to " + invite.Email + ", log in with that email address to accept it")
+		return
+	}
+
+	if err = Join(ctx, postgres, hash, user); err != nil {
+		_ = c.Error(err)
+		invalid("unable to join the organization")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  user.ID,
+		Action:   audit.ActionOrgMemberJoined,
+		Metadata: map[string]any{"org_id": uuid.UUID(invite.OrganizationID.Bytes).String(), "role": invite.Role},
+	})
+
+	session.Set("org_id", invite.OrganizationID.Bytes)
+	if err = session.Save(); err != nil {
+		_ = c.Error(err)
+	}
+
+	hx.SetRedirect("/")
+	c.Status(http.StatusOK)
+}
+
+// Join use the organization invite for the user's email address, adding them as a member.
+func Join(ctx context.Context, postgres *pgxpool.Pool, hash []byte, user dbx.AuthUser) error {
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := dbx.New(tx)
+	invite, err := qtx.UseOrganizationInvite(ctx, dbx.UseOrganizationInviteParams{
+		TokenHash: hash,
+		Email:     user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = qtx.CreateMembership(ctx, dbx.CreateMembershipParams{
+		OrganizationID: invite.OrganizationID,
+		UserID:         user.ID,
+		Role:           invite.Role,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
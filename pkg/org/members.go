@@ -0,0 +1,266 @@
+package org
+
+import (
+	"context"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MemberRoleRequest used in the change member role validation
+type MemberRoleRequest struct {
+	Role string `form:"role" binding:"required,oneof=owner admin member"`
+}
+
+// membersData the members page data for the current organization.
+func membersData(c *gin.Context) (pages.OrgMembersData, error) {
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+	org := appctx.MustOrg(c)
+	role := appctx.OrgRole(c)
+
+	data := pages.OrgMembersData{
+		Name:       org.Name,
+		CanManage:  role == RoleOwner || role == RoleAdmin,
+		IsOwner:    role == RoleOwner,
+		Roles:      Roles,
+		InviteRole: RoleMember,
+		Csrf:       csrf.GetToken(c),
+	}
+
+	rows, err := queries.ListMembershipsByOrganizationID(ctx, org.ID)
+	if err != nil {
+		return data, err
+	}
+	for _, row := range rows {
+		data.Members = append(data.Members, pages.OrgMemberRow{
+			UserID: uuid.UUID(row.UserID.Bytes).String(),
+			Name:   strings.TrimSpace(row.FirstName + " " + row.LastName),
+			Email:  row.Email,
+			Role:   row.Role,
+			Self:   row.UserID == user.ID,
+		})
+	}
+
+	if !data.CanManage {
+		return data, nil
+	}
+
+	invites, err := queries.ListOrganizationInvites(ctx, org.ID)
+	if err != nil {
+		return data, err
+	}
+	for _, invite := range invites {
+		data.Invites = append(data.Invites, pages.OrgInviteRow{
+			ID:        uuid.UUID(invite.ID.Bytes).String(),
+			Email:     invite.Email,
+			Role:      invite.Role,
+			ExpiresAt: invite.ExpiresAt.Time,
+		})
+	}
+	return data, nil
+}
+
+// renderMembers render the members page, with the notice and error if any.
+func renderMembers(c *gin.Context, status int, notice string, message string) {
+	data, err := membersData(c)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	data.Notice = notice
+	data.Error = message
+	html.Render(c, status, pages.OrgMembers(data))
+}
+
+// members get the members of the current organization.
+func members(c *gin.Context) {
+	renderMembers(c, http.StatusOK, "", "")
+}
+
+// memberParam get the user id in the path and their membership of the current organization,
+// responding not found if they are not a member.
+func memberParam(c *gin.Context) (pgtype.UUID, dbx.GetMembershipRow, bool) {
+	queries := appctx.Queries(c)
+	org := appctx.MustOrg(c)
+
+	var userID pgtype.UUID
+	if err := userID.Scan(c.Param("user_id")); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return userID, dbx.GetMembershipRow{}, false
+	}
+
+	member, err := queries.GetMembership(c.Request.Context(), dbx.GetMembershipParams{
+		OrganizationID: org.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return userID, dbx.GetMembershipRow{}, false
+	}
+	return userID, member, true
+}
+
+// lastOwner check if the user is the organization's only owner, who cannot be removed or demoted.
+// Run it in the transaction making the change, the owners stay locked until it ends
+// so concurrent changes cannot each see another owner and remove them all.
+func lastOwner(ctx context.Context, queries *dbx.Queries, orgID pgtype.UUID, userID pgtype.UUID) (bool, error) {
+	owners, err := queries.LockOrganizationOwners(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	return len(owners) == 1 && owners[0] == userID, nil
+}
+
+// updateMemberRole change the role of a member of the current organization,
+// only owners may make or change owners.
+func updateMemberRole(c *gin.Context) {
+	ctx := c.Request.Context()
+	postgres := appctx.Postgres(c)
+	org := appctx.MustOrg(c)
+
+	userID, member, ok := memberParam(c)
+	if !ok {
+		return
+	}
+
+	var request MemberRoleRequest
+	if err := c.ShouldBind(&request); err != nil {
+		renderMembers(c, http.StatusUnprocessableEntity, "", "please choose a valid role")
+		return
+	}
+
+	isOwner := appctx.OrgRole(c) == RoleOwner
+	if !isOwner && (request.Role == RoleOwner || member.Role == RoleOwner) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := dbx.New(tx)
+
+	if request.Role != RoleOwner {
+		last, err := lastOwner(ctx, qtx, org.ID, userID)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if last {
+			renderMembers(c, http.StatusUnprocessableEntity, "", "an organization must keep at least one owner")
+			return
+		}
+	}
+
+	if _, err = qtx.UpdateMembershipRole(ctx, dbx.UpdateMembershipRoleParams{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           request.Role,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  userID,
+		Action:   audit.ActionOrgMemberRoleChanged,
+		Metadata: map[string]any{"org_id": uuid.UUID(org.ID.Bytes).String(), "from": member.Role, "to": request.Role},
+	})
+
+	renderMembers(c, http.StatusOK, "", "")
+}
+
+// removeMember remove a member from the current organization, or leave it when removing yourself.
+// Owners and admins remove others, only owners remove owners.
+func removeMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	postgres := appctx.Postgres(c)
+	session := appctx.Session(c)
+	user := appctx.MustCurrentUser(c)
+	org := appctx.MustOrg(c)
+	role := appctx.OrgRole(c)
+
+	userID, member, ok := memberParam(c)
+	if !ok {
+		return
+	}
+
+	self := userID == user.ID
+
+	canManage := slices.Contains([]string{RoleOwner, RoleAdmin}, role)
+	if !self && (!canManage || (member.Role == RoleOwner && role != RoleOwner)) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := dbx.New(tx)
+
+	last, err := lastOwner(ctx, qtx, org.ID, userID)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if last {
+		renderMembers(c, http.StatusUnprocessableEntity, "", "an organization must keep at least one owner")
+		return
+	}
+
+	if _, err = qtx.DeleteMembership(ctx, dbx.DeleteMembershipParams{
+		OrganizationID: org.ID,
+		UserID:         userID,
+	}); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  userID,
+		Action:   audit.ActionOrgMemberRemoved,
+		Metadata: map[string]any{"org_id": uuid.UUID(org.ID.Bytes).String(), "left": self},
+	})
+
+	if self {
+		session.Delete("org_id")
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+		}
+		if hx.IsHTMXRequest() {
+			hx.SetRedirect("/")
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	renderMembers(c, http.StatusOK, "", "")
+}
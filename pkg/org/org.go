@@ -0,0 +1,181 @@
+package org
+
+import (
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/html"
+	"gin.go.dev/pkg/ui/components"
+	"gin.go.dev/pkg/ui/pages"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	csrf "github.com/stuartaccent/gin-csrf"
+	"net/http"
+	"strings"
+)
+
+// The roles of a member within an organization.
+// Owners and admins manage the members, only owners manage other owners.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Roles every role, most privileged first.
+var Roles = []string{RoleOwner, RoleAdmin, RoleMember}
+
+// OrgDetails used in the new organization validation
+type OrgDetails struct {
+	Name string `form:"name" binding:"required,max=120"`
+}
+
+// SwitchRequest used in the switch organization validation
+type SwitchRequest struct {
+	OrgID string `form:"org_id" binding:"required,uuid"`
+}
+
+// switcher the organization switcher in the header.
+func switcher(c *gin.Context) {
+	queries := appctx.Queries(c)
+	user := appctx.MustCurrentUser(c)
+	_, open := c.GetQuery("open")
+
+	orgs, err := queries.ListOrganizationsByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	current, _ := appctx.Org(c)
+
+	options := make([]components.OrgOption, 0, len(orgs))
+	for _, o := range orgs {
+		options = append(options, components.OrgOption{
+			ID:      uuid.UUID(o.Organization.ID.Bytes).String(),
+			Name:    o.Organization.Name,
+			Current: o.Organization.ID == current.ID,
+		})
+	}
+
+	html.Render(c, http.StatusOK, components.OrgSwitcher(open, current.Name, options, csrf.GetToken(c)))
+}
+
+// switchOrg make one of the user's organizations the current one then refresh the page.
+func switchOrg(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+	user := appctx.MustCurrentUser(c)
+
+	var request SwitchRequest
+	var orgID pgtype.UUID
+	if err := c.ShouldBind(&request); err != nil || orgID.Scan(request.OrgID) != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if _, err := queries.GetMembership(ctx, dbx.GetMembershipParams{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+	}); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	session.Set("org_id", orgID.Bytes)
+	if err := session.Save(); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if hx.IsHTMXRequest() {
+		hx.SetRefresh()
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Redirect(http.StatusFound, "/")
+}
+
+// newOrgForm get the new organization form
+func newOrgForm(c *gin.Context) {
+	html.Render(c, http.StatusOK, pages.OrgNew(pages.OrgNewData{
+		Csrf: csrf.GetToken(c),
+	}))
+}
+
+// createOrg create an organization with the user as its owner,
+// make it the current one then redirect to its members.
+func createOrg(c *gin.Context) {
+	ctx := c.Request.Context()
+	hx := appctx.HTMX(c)
+	postgres := appctx.Postgres(c)
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+	user := appctx.MustCurrentUser(c)
+
+	var details OrgDetails
+	bindErr := c.ShouldBind(&details)
+
+	invalid := func(message string) {
+		html.Render(c, http.StatusUnprocessableEntity, pages.OrgNew(pages.OrgNewData{
+			Name:  details.Name,
+			Error: message,
+			Csrf:  csrf.GetToken(c),
+		}))
+	}
+
+	name := strings.TrimSpace(details.Name)
+	if bindErr != nil || name == "" {
+		invalid("please enter a name of at most 120 characters")
+		return
+	}
+
+	tx, err := postgres.Begin(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to create the organization")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := queries.WithTx(tx)
+	org, err := qtx.CreateOrganization(ctx, name)
+	if err != nil {
+		_ = c.Error(err)
+		invalid("unable to create the organization")
+		return
+	}
+
+	if err = qtx.CreateMembership(ctx, dbx.CreateMembershipParams{
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		Role:           RoleOwner,
+	}); err != nil {
+		_ = c.Error(err)
+		invalid("unable to create the organization")
+		return
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		_ = c.Error(err)
+		invalid("unable to create the organization")
+		return
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Subject:  user.ID,
+		Action:   audit.ActionOrgCreated,
+		Metadata: map[string]any{"org_id": uuid.UUID(org.ID.Bytes).String(), "name": org.Name},
+	})
+
+	session.Set("org_id", org.ID.Bytes)
+	if err = session.Save(); err != nil {
+		_ = c.Error(err)
+	}
+
+	hx.SetRedirect("/orgs/members")
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,38 @@
+package org
+
+import (
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/mail"
+	"gin.go.dev/pkg/transport/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Router create a new Router for organizations and their members.
+// The mailer is used to send invites containing links built from the server base url.
+func Router(e *gin.Engine, csrf gin.HandlerFunc, cfg *config.Config, mailer mail.Sender) {
+	baseURL := cfg.Server.BaseURL
+	limiter := middleware.RateLimit("orgs")
+	allowForm := middleware.AllowContentType("application/x-www-form-urlencoded")
+	auth := middleware.Authenticated()
+	manage := middleware.RequireOrg(RoleOwner, RoleAdmin)
+
+	g := e.Group("/orgs", auth, middleware.Verified(), middleware.CurrentOrg())
+	{
+		g.GET("/switcher", csrf, switcher)
+		g.POST("/switch", allowForm, csrf, switchOrg)
+		g.GET("/new", csrf, newOrgForm)
+		g.POST("/new", allowForm, csrf, createOrg)
+		g.GET("/members", middleware.RequireOrg(), csrf, members)
+		g.POST("/members/:user_id/role", manage, allowForm, csrf, updateMemberRole)
+		g.POST("/members/:user_id/remove", middleware.RequireOrg(), allowForm, csrf, removeMember)
+		g.POST("/invites", limiter, manage, allowForm, csrf, createInvite(mailer, baseURL))
+		g.POST("/invites/:id/revoke", manage, allowForm, csrf, revokeInvite)
+	}
+
+	register := ""
+	if cfg.Registration.Enabled {
+		register = "/auth/register"
+	}
+	e.GET("/orgs/join/:token", csrf, joinForm(register))
+	e.POST("/orgs/join/:token", limiter, auth, allowForm, csrf, join)
+}
@@ -0,0 +1,138 @@
+// Renders the OpenAPI document of the JSON API, see /api/docs.
+(() => {
+    const root = document.getElementById("docs");
+    if (!root) {
+        return;
+    }
+
+    const el = (tag, attrs = {}, ...children) => {
+        const node = document.createElement(tag);
+        Object.entries(attrs).forEach(([k, v]) => node.setAttribute(k, v));
+        children.forEach((child) => node.append(child));
+        return node;
+    };
+
+    // resolve follow a $ref to its component schema.
+    const resolve = (spec, schema) => {
+        if (schema && schema.$ref) {
+            return spec.components.schemas[schema.$ref.split("/").pop()];
+        }
+        return schema || {};
+    };
+
+    // example build an example value of the schema.
+    const example = (spec, schema, depth = 0) => {
+        schema = resolve(spec, schema);
+        if (depth > 5) {
+            return null;
+        }
+        if (schema.enum) {
+            return schema.enum[0];
+        }
+        switch (schema.type) {
+            case "object":
+                if (schema.additionalProperties) {
+                    return {key: example(spec, schema.additionalProperties, depth + 1)};
+                }
+                return Object.fromEntries(Object.entries(schema.properties || {})
+                    .map(([name, prop]) => [name, example(spec, prop, depth + 1)]));
+            case "array":
+                return [example(spec, schema.items, depth + 1)];
+            case "integer":
+            case "number":
+                return schema.minimum || 1;
+            case "boolean":
+                return false;
+            case "string":
+                return {
+                    "date-time": "2024-01-01T00:00:00Z",
+                    "email": "user@example.com",
+                    "uuid": "00000000-0000-0000-0000-000000000000",
+                }[schema.format] || "string";
+            default:
+                return null;
+        }
+    };
+
+    // rules describe the validation rules of the schema.
+    const rules = (schema, required) => {
+        const out = [];
+        if (required) out.push("required");
+        if (schema.format) out.push(schema.format);
+        if (schema.minLength) out.push(`min length ${schema.minLength}`);
+        if (schema.maxLength) out.push(`max length ${schema.maxLength}`);
+        if (schema.minimum !== undefined) out.push(`min ${schema.minimum}`);
+        if (schema.maximum !== undefined) out.push(`max ${schema.maximum}`);
+        if (schema.enum) out.push(`one of ${schema.enum.join(", ")}`);
+        if (schema.description) out.push(schema.description);
+        return out.join(", ");
+    };
+
+    const fieldsTable = (spec, schema) => {
+        schema = resolve(spec, schema);
+        const required = schema.required || [];
+        return el("table", {}, ...Object.entries(schema.properties || {}).map(([name, prop]) =>
+            el("tr", {},
+                el("td", {}, el("code", {}, name)),
+                el("td", {}, resolve(spec, prop).type || "object"),
+                el("td", {}, rules(resolve(spec, prop), required.includes(name))))));
+    };
+
+    const operation = (spec, method, path, op) => {
+        const node = el("section", {class: "docs-operation", id: op.operationId || ""},
+            el("h4", {},
+                el("span", {class: `docs-method docs-method-${method}`}, method),
+                el("code", {}, path),
+                op.security && op.security.length === 0 ? el("span", {class: "docs-public"}, "no authentication") : ""));
+
+        if (op.summary) node.append(el("p", {}, op.summary));
+        if (op.description) node.append(el("p", {}, op.description));
+
+        if (op.parameters && op.parameters.length) {
+            node.append(el("h5", {}, "Parameters"), el("table", {}, ...op.parameters.map((p) =>
+                el("tr", {},
+                    el("td", {}, el("code", {}, p.name)),
+                    el("td", {}, p.in),
+                    el("td", {}, [p.schema.type, p.required ? "required" : "", p.description || ""].filter(Boolean).join(", "))))));
+        }
+
+        if (op.requestBody) {
+            const schema = op.requestBody.content["application/json"].schema;
+            node.append(el("h5", {}, "Request body"), fieldsTable(spec, schema),
+                el("pre", {}, JSON.stringify(example(spec, schema), null, 2)));
+        }
+
+        Object.entries(op.responses).filter(([status]) => status !== "default").forEach(([status, response]) => {
+            node.append(el("h5", {}, `Response ${status} ${response.description}`));
+            if (response.content) {
+                const schema = response.content["application/json"].schema;
+                node.append(el("pre", {}, JSON.stringify(example(spec, schema), null, 2)));
+            }
+        });
+
+        return node;
+    };
+
+    fetch(root.dataset.spec, {headers: {Accept: "application/json"}})
+        .then((res) => res.json())
+        .then((spec) => {
+            document.getElementById("docs-title").textContent = `${spec.info.title} ${spec.info.version}`;
+
+            const tags = new Map();
+            Object.entries(spec.paths).forEach(([path, methods]) => {
+                Object.entries(methods).forEach(([method, op]) => {
+                    const tag = (op.tags || ["Other"])[0];
+                    if (!tags.has(tag)) tags.set(tag, []);
+                    tags.get(tag).push(operation(spec, method, path, op));
+                });
+            });
+
+            const operations = document.getElementById("docs-operations");
+            tags.forEach((nodes, tag) => {
+                operations.append(el("h3", {class: "owl-h3 docs-tag"}, tag), ...nodes);
+            });
+        })
+        .catch((err) => {
+            document.getElementById("docs-operations").append(el("p", {class: "owl-p"}, `Unable to load the API document: ${err}`));
+        });
+})();
@@ -1,8 +1,12 @@
-// This script is used to handle the 404 and 422 errors in the htmx requests.
+// This script is used to handle the 403, 404, 422 and 429 errors in the htmx requests.
 document.addEventListener('DOMContentLoaded', function() {
   document.body.addEventListener('htmx:beforeSwap', function (evt) {
-    if(evt.detail.xhr.status === 404){
+    if(evt.detail.xhr.status === 403){
+      alert("Error: Forbidden (403)");
+    } else if(evt.detail.xhr.status === 404){
       alert("Error: Not Found (404)");
+    } else if(evt.detail.xhr.status === 429){
+      alert("Error: Too many requests, try again later (429)");
     } else if (evt.detail.xhr.status === 422) {
       evt.detail.shouldSwap = true;
       evt.detail.isError = false;
@@ -0,0 +1,90 @@
+// This script handles the passkey registration and sign in ceremonies.
+(function () {
+  function toBytes(value) {
+    const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+    const binary = atob(base64.padEnd(base64.length + (4 - base64.length % 4) % 4, '='));
+    return Uint8Array.from(binary, c => c.charCodeAt(0));
+  }
+
+  function toBase64(buffer) {
+    const binary = String.fromCharCode(...new Uint8Array(buffer));
+    return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+  }
+
+  async function post(url, csrf, body) {
+    const response = await fetch(url, {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json', 'X-CSRF-TOKEN': csrf},
+      body: body ? JSON.stringify(body) : null,
+    });
+    const data = await response.json();
+    if (!response.ok) {
+      throw new Error(data.error || 'Something went wrong');
+    }
+    return data;
+  }
+
+  async function register(button) {
+    const csrf = button.dataset.csrf;
+    const options = await post('/auth/passkeys/register/begin', csrf);
+    const publicKey = options.publicKey;
+    publicKey.challenge = toBytes(publicKey.challenge);
+    publicKey.user.id = toBytes(publicKey.user.id);
+    (publicKey.excludeCredentials || []).forEach(c => c.id = toBytes(c.id));
+
+    const credential = await navigator.credentials.create({publicKey});
+    const name = document.querySelector(button.dataset.passkeyName);
+    const query = name && name.value ? '?name=' + encodeURIComponent(name.value) : '';
+    return post('/auth/passkeys/register/finish' + query, csrf, {
+      id: credential.id,
+      rawId: toBase64(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: toBase64(credential.response.clientDataJSON),
+        attestationObject: toBase64(credential.response.attestationObject),
+        transports: credential.response.getTransports ? credential.response.getTransports() : [],
+      },
+    });
+  }
+
+  async function login(button) {
+    const csrf = button.dataset.csrf;
+    const options = await post('/auth/passkeys/login/begin', csrf);
+    const publicKey = options.publicKey;
+    publicKey.challenge = toBytes(publicKey.challenge);
+    (publicKey.allowCredentials || []).forEach(c => c.id = toBytes(c.id));
+
+    const credential = await navigator.credentials.get({publicKey});
+    return post('/auth/passkeys/login/finish', csrf, {
+      id: credential.id,
+      rawId: toBase64(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: toBase64(credential.response.clientDataJSON),
+        authenticatorData: toBase64(credential.response.authenticatorData),
+        signature: toBase64(credential.response.signature),
+        userHandle: credential.response.userHandle ? toBase64(credential.response.userHandle) : null,
+      },
+    });
+  }
+
+  document.addEventListener('click', async function (evt) {
+    const button = evt.target.closest('[data-passkey-register], [data-passkey-login]');
+    if (!button) {
+      return;
+    }
+    const error = button.parentElement.querySelector('[data-passkey-error]');
+    if (error) {
+      error.textContent = '';
+    }
+    try {
+      const ceremony = button.hasAttribute('data-passkey-register') ? register : login;
+      const result = await ceremony(button);
+      window.location.href = result.redirect;
+    } catch (err) {
+      if (error) {
+        error.textContent = err.message;
+      }
+    }
+  });
+})();
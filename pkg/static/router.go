@@ -13,9 +13,12 @@ var (
 	Public embed.FS
 )
 
-// Router create a new static router.
+// Router create a new static router, including the API documentation page.
 func Router(e *gin.Engine) {
 	e.StaticFS("/static", staticFS())
+	e.GET("/api/docs", func(c *gin.Context) {
+		c.FileFromFS("docs/", staticFS())
+	})
 }
 
 // staticFS returns the static file system.
@@ -0,0 +1,150 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: access_tokens.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAccessToken = `-- name: CreateAccessToken :one
+INSERT INTO auth_access_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at
+`
+
+type CreateAccessTokenParams struct {
+	UserID    pgtype.UUID
+	Name      string
+	Prefix    string
+	TokenHash []byte
+	Scopes    []string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// create a new personal access token for a user
+func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) (AuthAccessToken, error) {
+	row := q.db.QueryRow(ctx, createAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i AuthAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAccessTokenByIDAndUserID = `-- name: DeleteAccessTokenByIDAndUserID :execrows
+DELETE
+FROM auth_access_tokens
+WHERE id = $1
+  AND user_id = $2
+`
+
+type DeleteAccessTokenByIDAndUserIDParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+// revoke one of a user's access tokens
+func (q *Queries) DeleteAccessTokenByIDAndUserID(ctx context.Context, arg DeleteAccessTokenByIDAndUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAccessTokenByIDAndUserID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAccessTokenByHash = `-- name: GetAccessTokenByHash :one
+SELECT id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at
+FROM auth_access_tokens
+WHERE token_hash = $1
+  AND (expires_at IS NULL OR expires_at > clock_timestamp())
+LIMIT 1
+`
+
+// get an unexpired access token by its hash
+func (q *Queries) GetAccessTokenByHash(ctx context.Context, tokenHash []byte) (AuthAccessToken, error) {
+	row := q.db.QueryRow(ctx, getAccessTokenByHash, tokenHash)
+	var i AuthAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccessTokensByUserID = `-- name: ListAccessTokensByUserID :many
+SELECT id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at
+FROM auth_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+// list a user's access tokens, newest first
+func (q *Queries) ListAccessTokensByUserID(ctx context.Context, userID pgtype.UUID) ([]AuthAccessToken, error) {
+	rows, err := q.db.Query(ctx, listAccessTokensByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuthAccessToken{}
+	for rows.Next() {
+		var i AuthAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchAccessToken = `-- name: TouchAccessToken :exec
+UPDATE auth_access_tokens
+SET last_used_at = clock_timestamp()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < clock_timestamp() - interval '1 minute')
+`
+
+// record an access token as used, at most once a minute
+func (q *Queries) TouchAccessToken(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchAccessToken, id)
+	return err
+}
@@ -0,0 +1,185 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: audit.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (actor_id, subject_id, action, ip, user_agent, metadata)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateAuditEventParams struct {
+	ActorID   pgtype.UUID
+	SubjectID pgtype.UUID
+	Action    string
+	Ip        string
+	UserAgent string
+	Metadata  []byte
+}
+
+// append an event to the audit log
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.Exec(ctx, createAuditEvent,
+		arg.ActorID,
+		arg.SubjectID,
+		arg.Action,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Metadata,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT e.id, e.actor_id, e.subject_id, e.action, e.ip, e.user_agent, e.metadata, e.created_at,
+       coalesce(a.email, '')::text AS actor_email,
+       coalesce(s.email, '')::text AS subject_email
+FROM audit_events e
+         LEFT JOIN auth_users a ON a.id = e.actor_id
+         LEFT JOIN auth_users s ON s.id = e.subject_id
+WHERE e.action LIKE $1::text || '%'
+  AND ($2::text = '' OR a.email = $2 OR s.email = $2)
+  AND ($3::timestamptz IS NULL OR e.created_at >= $3)
+  AND ($4::bigint = 0 OR e.id < $4)
+ORDER BY e.id DESC
+LIMIT $5
+`
+
+type ListAuditEventsParams struct {
+	Action   string
+	Email    string
+	Since    pgtype.Timestamptz
+	BeforeID int64
+	RowLimit int32
+}
+
+type ListAuditEventsRow struct {
+	ID           int64
+	ActorID      pgtype.UUID
+	SubjectID    pgtype.UUID
+	Action       string
+	Ip           string
+	UserAgent    string
+	Metadata     []byte
+	CreatedAt    pgtype.Timestamptz
+	ActorEmail   string
+	SubjectEmail string
+}
+
+// list audit events newest first, filtered by action prefix, actor or subject email and time,
+// paged by the id of the last event seen
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]ListAuditEventsRow, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.Action,
+		arg.Email,
+		arg.Since,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAuditEventsRow{}
+	for rows.Next() {
+		var i ListAuditEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.SubjectID,
+			&i.Action,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.ActorEmail,
+			&i.SubjectEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEventsAfter = `-- name: ListAuditEventsAfter :many
+SELECT e.id, e.actor_id, e.subject_id, e.action, e.ip, e.user_agent, e.metadata, e.created_at,
+       coalesce(a.email, '')::text AS actor_email,
+       coalesce(s.email, '')::text AS subject_email
+FROM audit_events e
+         LEFT JOIN auth_users a ON a.id = e.actor_id
+         LEFT JOIN auth_users s ON s.id = e.subject_id
+WHERE e.action LIKE $1::text || '%'
+  AND ($2::text = '' OR a.email = $2 OR s.email = $2)
+  AND e.id > $3
+ORDER BY e.id
+LIMIT $4
+`
+
+type ListAuditEventsAfterParams struct {
+	Action   string
+	Email    string
+	AfterID  int64
+	RowLimit int32
+}
+
+type ListAuditEventsAfterRow struct {
+	ID           int64
+	ActorID      pgtype.UUID
+	SubjectID    pgtype.UUID
+	Action       string
+	Ip           string
+	UserAgent    string
+	Metadata     []byte
+	CreatedAt    pgtype.Timestamptz
+	ActorEmail   string
+	SubjectEmail string
+}
+
+// list audit events oldest first after the id given, with the same filters as ListAuditEvents
+func (q *Queries) ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]ListAuditEventsAfterRow, error) {
+	rows, err := q.db.Query(ctx, listAuditEventsAfter,
+		arg.Action,
+		arg.Email,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAuditEventsAfterRow{}
+	for rows.Next() {
+		var i ListAuditEventsAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.SubjectID,
+			&i.Action,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.ActorEmail,
+			&i.SubjectEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,6 +11,150 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countRecoveryCodes = `-- name: CountRecoveryCodes :one
+SELECT count(*)
+FROM auth_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL
+`
+
+// count a user's unused recovery codes
+func (q *Queries) CountRecoveryCodes(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*)
+FROM auth_users
+WHERE $1::text = ''
+   OR strpos(lower(email), lower($1)) > 0
+   OR strpos(lower(first_name || ' ' || last_name), lower($1)) > 0
+`
+
+// count users matching the search by email or name
+func (q *Queries) CountUsers(ctx context.Context, search string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createEmailVerification = `-- name: CreateEmailVerification :one
+INSERT INTO auth_email_verifications (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, expires_at, created_at
+`
+
+type CreateEmailVerificationParams struct {
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+// create a new email verification token for a user
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (AuthEmailVerification, error) {
+	row := q.db.QueryRow(ctx, createEmailVerification, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i AuthEmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createIdentity = `-- name: CreateIdentity :exec
+INSERT INTO auth_identities (user_id, provider, subject)
+VALUES ($1, $2, $3)
+`
+
+type CreateIdentityParams struct {
+	UserID   pgtype.UUID
+	Provider string
+	Subject  string
+}
+
+// link an identity provider subject to a user
+func (q *Queries) CreateIdentity(ctx context.Context, arg CreateIdentityParams) error {
+	_, err := q.db.Exec(ctx, createIdentity, arg.UserID, arg.Provider, arg.Subject)
+	return err
+}
+
+const createInvite = `-- name: CreateInvite :one
+INSERT INTO auth_invites (email, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, email, token_hash, expires_at, used_at, created_at
+`
+
+type CreateInviteParams struct {
+	Email     string
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+// create a new registration invite for an email address
+func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) (AuthInvite, error) {
+	row := q.db.QueryRow(ctx, createInvite, arg.Email, arg.TokenHash, arg.ExpiresAt)
+	var i AuthInvite
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO auth_password_resets (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, expires_at, used_at, created_at
+`
+
+type CreatePasswordResetParams struct {
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+// create a new password reset token for a user
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (AuthPasswordReset, error) {
+	row := q.db.QueryRow(ctx, createPasswordReset, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i AuthPasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO auth_recovery_codes (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   pgtype.UUID
+	CodeHash []byte
+}
+
+// create a new two factor recovery code for a user
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO auth_users (email, hashed_password, first_name, last_name)
 VALUES ($1, $2, $3, $4)
@@ -47,6 +191,237 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (AuthUse
 	return i, err
 }
 
+const createWebAuthnCredential = `-- name: CreateWebAuthnCredential :one
+INSERT INTO auth_webauthn_credentials (user_id, credential_id, public_key, attestation_type, aaguid, sign_count,
+                                       transports, backup_eligible, backup_state, name)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name, created_at, last_used_at
+`
+
+type CreateWebAuthnCredentialParams struct {
+	UserID          pgtype.UUID
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Aaguid          []byte
+	SignCount       int64
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	Name            string
+}
+
+// create a new webauthn credential for a user
+func (q *Queries) CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (AuthWebauthnCredential, error) {
+	row := q.db.QueryRow(ctx, createWebAuthnCredential,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.AttestationType,
+		arg.Aaguid,
+		arg.SignCount,
+		arg.Transports,
+		arg.BackupEligible,
+		arg.BackupState,
+		arg.Name,
+	)
+	var i AuthWebauthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.AttestationType,
+		&i.Aaguid,
+		&i.SignCount,
+		&i.Transports,
+		&i.BackupEligible,
+		&i.BackupState,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const deleteEmailVerificationsByUserID = `-- name: DeleteEmailVerificationsByUserID :exec
+DELETE
+FROM auth_email_verifications
+WHERE user_id = $1
+`
+
+// delete all email verifications for a user
+func (q *Queries) DeleteEmailVerificationsByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEmailVerificationsByUserID, userID)
+	return err
+}
+
+const deleteLockoutByUserID = `-- name: DeleteLockoutByUserID :exec
+DELETE
+FROM auth_lockouts
+WHERE user_id = $1
+`
+
+// clear a user's failed logins and lockouts
+func (q *Queries) DeleteLockoutByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteLockoutByUserID, userID)
+	return err
+}
+
+const deletePasswordResetsByUserID = `-- name: DeletePasswordResetsByUserID :exec
+DELETE
+FROM auth_password_resets
+WHERE user_id = $1
+`
+
+// delete all password resets for a user
+func (q *Queries) DeletePasswordResetsByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deletePasswordResetsByUserID, userID)
+	return err
+}
+
+const deleteRecoveryCodesByUserID = `-- name: DeleteRecoveryCodesByUserID :exec
+DELETE
+FROM auth_recovery_codes
+WHERE user_id = $1
+`
+
+// delete all recovery codes for a user
+func (q *Queries) DeleteRecoveryCodesByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodesByUserID, userID)
+	return err
+}
+
+const deleteTOTPByUserID = `-- name: DeleteTOTPByUserID :exec
+DELETE
+FROM auth_totp
+WHERE user_id = $1
+`
+
+// delete a user's totp secret disabling two factor
+func (q *Queries) DeleteTOTPByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteTOTPByUserID, userID)
+	return err
+}
+
+const deleteWebAuthnCredential = `-- name: DeleteWebAuthnCredential :exec
+DELETE
+FROM auth_webauthn_credentials
+WHERE id = $1
+  AND user_id = $2
+`
+
+type DeleteWebAuthnCredentialParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+// delete one of a user's webauthn credentials
+func (q *Queries) DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) error {
+	_, err := q.db.Exec(ctx, deleteWebAuthnCredential, arg.ID, arg.UserID)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :exec
+UPDATE auth_totp
+SET enabled_at = clock_timestamp()
+WHERE user_id = $1
+`
+
+// enable two factor for a user
+func (q *Queries) EnableTOTP(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, enableTOTP, userID)
+	return err
+}
+
+const getInvite = `-- name: GetInvite :one
+SELECT id, email, token_hash, expires_at, used_at, created_at
+FROM auth_invites
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1
+`
+
+// get an unused and unexpired invite by its token hash
+func (q *Queries) GetInvite(ctx context.Context, tokenHash []byte) (AuthInvite, error) {
+	row := q.db.QueryRow(ctx, getInvite, tokenHash)
+	var i AuthInvite
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPasswordReset = `-- name: GetPasswordReset :one
+SELECT id, user_id, token_hash, expires_at, used_at, created_at
+FROM auth_password_resets
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1
+`
+
+// get an unused and unexpired password reset by its token hash
+func (q *Queries) GetPasswordReset(ctx context.Context, tokenHash []byte) (AuthPasswordReset, error) {
+	row := q.db.QueryRow(ctx, getPasswordReset, tokenHash)
+	var i AuthPasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRoleByName = `-- name: GetRoleByName :one
+SELECT id, name, description, created_at
+FROM auth_roles
+WHERE name = $1
+`
+
+// get a role by name
+func (q *Queries) GetRoleByName(ctx context.Context, name string) (AuthRole, error) {
+	row := q.db.QueryRow(ctx, getRoleByName, name)
+	var i AuthRole
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTOTPByUserID = `-- name: GetTOTPByUserID :one
+SELECT user_id, secret, last_step, enabled_at, created_at
+FROM auth_totp
+WHERE user_id = $1
+LIMIT 1
+`
+
+// get a user's totp secret
+func (q *Queries) GetTOTPByUserID(ctx context.Context, userID pgtype.UUID) (AuthTotp, error) {
+	row := q.db.QueryRow(ctx, getTOTPByUserID, userID)
+	var i AuthTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.LastStep,
+		&i.EnabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
 SELECT id, email, hashed_password, first_name, last_name, is_active, is_verified, created_at, updated_at
 FROM auth_users
@@ -97,6 +472,352 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (AuthUser, er
 	return i, err
 }
 
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT auth_users.id, auth_users.email, auth_users.hashed_password, auth_users.first_name, auth_users.last_name, auth_users.is_active, auth_users.is_verified, auth_users.created_at, auth_users.updated_at
+FROM auth_users
+         JOIN auth_identities ON auth_identities.user_id = auth_users.id
+WHERE auth_identities.provider = $1
+  AND auth_identities.subject = $2
+LIMIT 1
+`
+
+type GetUserByIdentityParams struct {
+	Provider string
+	Subject  string
+}
+
+// get the user linked to an identity provider subject
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (AuthUser, error) {
+	row := q.db.QueryRow(ctx, getUserByIdentity, arg.Provider, arg.Subject)
+	var i AuthUser
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsActive,
+		&i.IsVerified,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT INTO auth_user_roles (user_id, role_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type GrantUserRoleParams struct {
+	UserID pgtype.UUID
+	RoleID pgtype.UUID
+}
+
+// grant a role to a user
+func (q *Queries) GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error {
+	_, err := q.db.Exec(ctx, grantUserRole, arg.UserID, arg.RoleID)
+	return err
+}
+
+const isUserLocked = `-- name: IsUserLocked :one
+SELECT EXISTS (SELECT 1
+               FROM auth_lockouts
+               WHERE user_id = $1
+                 AND locked_until > clock_timestamp())
+`
+
+// check if a user is locked out of password login
+func (q *Queries) IsUserLocked(ctx context.Context, userID pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserLocked, userID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listPermissionNamesByUserID = `-- name: ListPermissionNamesByUserID :many
+SELECT DISTINCT p.name
+FROM auth_permissions p
+         JOIN auth_role_permissions rp ON rp.permission_id = p.id
+         JOIN auth_user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name
+`
+
+// list the names of the permissions granted to a user through their roles
+func (q *Queries) ListPermissionNamesByUserID(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listPermissionNamesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoleNamesByUserID = `-- name: ListRoleNamesByUserID :many
+SELECT r.name
+FROM auth_roles r
+         JOIN auth_user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = $1
+ORDER BY r.name
+`
+
+// list the names of the roles granted to a user
+func (q *Queries) ListRoleNamesByUserID(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listRoleNamesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, email, hashed_password, first_name, last_name, is_active, is_verified, created_at, updated_at
+FROM auth_users
+WHERE $1::text = ''
+   OR strpos(lower(email), lower($1)) > 0
+   OR strpos(lower(first_name || ' ' || last_name), lower($1)) > 0
+ORDER BY email
+LIMIT $3 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Search    string
+	RowOffset int32
+	RowLimit  int32
+}
+
+// list users whose email or name contains the search, ignoring case, ordered by email.
+// strpos rather than ILIKE so % and _ in the search are matched literally
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]AuthUser, error) {
+	rows, err := q.db.Query(ctx, listUsers, arg.Search, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuthUser{}
+	for rows.Next() {
+		var i AuthUser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.HashedPassword,
+			&i.FirstName,
+			&i.LastName,
+			&i.IsActive,
+			&i.IsVerified,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebAuthnCredentialsByUserID = `-- name: ListWebAuthnCredentialsByUserID :many
+SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state, name, created_at, last_used_at
+FROM auth_webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+// list a user's webauthn credentials
+func (q *Queries) ListWebAuthnCredentialsByUserID(ctx context.Context, userID pgtype.UUID) ([]AuthWebauthnCredential, error) {
+	rows, err := q.db.Query(ctx, listWebAuthnCredentialsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuthWebauthnCredential{}
+	for rows.Next() {
+		var i AuthWebauthnCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.AttestationType,
+			&i.Aaguid,
+			&i.SignCount,
+			&i.Transports,
+			&i.BackupEligible,
+			&i.BackupState,
+			&i.Name,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE auth_lockouts
+SET failed_attempts = 0,
+    lockouts        = lockouts + 1,
+    locked_until    = $2
+WHERE user_id = $1
+`
+
+type LockUserParams struct {
+	UserID      pgtype.UUID
+	LockedUntil pgtype.Timestamptz
+}
+
+// lock a user out of password login until the time given
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.Exec(ctx, lockUser, arg.UserID, arg.LockedUntil)
+	return err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+INSERT INTO auth_lockouts (user_id, failed_attempts)
+VALUES ($1, 1)
+ON CONFLICT (user_id) DO UPDATE
+    SET failed_attempts = auth_lockouts.failed_attempts + 1,
+        last_failed_at  = clock_timestamp()
+RETURNING user_id, failed_attempts, lockouts, locked_until, last_failed_at
+`
+
+// count a failed password login for a user
+func (q *Queries) RecordFailedLogin(ctx context.Context, userID pgtype.UUID) (AuthLockout, error) {
+	row := q.db.QueryRow(ctx, recordFailedLogin, userID)
+	var i AuthLockout
+	err := row.Scan(
+		&i.UserID,
+		&i.FailedAttempts,
+		&i.Lockouts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+	)
+	return i, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE auth_users
+SET hashed_password = $1
+WHERE id = $2
+  AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHashedPassword []byte
+	ID                pgtype.UUID
+	OldHashedPassword []byte
+}
+
+// replace a user's password hash if it has not changed since it was read
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, rehashUserPassword, arg.NewHashedPassword, arg.ID, arg.OldHashedPassword)
+	return err
+}
+
+const revokeUserRole = `-- name: RevokeUserRole :execrows
+DELETE
+FROM auth_user_roles
+WHERE user_id = $1
+  AND role_id = $2
+`
+
+type RevokeUserRoleParams struct {
+	UserID pgtype.UUID
+	RoleID pgtype.UUID
+}
+
+// revoke a role from a user
+func (q *Queries) RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserRole, arg.UserID, arg.RoleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setPendingTOTP = `-- name: SetPendingTOTP :exec
+INSERT INTO auth_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret     = excluded.secret,
+        last_step  = 0,
+        created_at = clock_timestamp()
+WHERE auth_totp.enabled_at IS NULL
+`
+
+type SetPendingTOTPParams struct {
+	UserID pgtype.UUID
+	Secret []byte
+}
+
+// set a new totp secret for a user who has not yet enabled two factor
+func (q *Queries) SetPendingTOTP(ctx context.Context, arg SetPendingTOTPParams) error {
+	_, err := q.db.Exec(ctx, setPendingTOTP, arg.UserID, arg.Secret)
+	return err
+}
+
+const setUserActiveByID = `-- name: SetUserActiveByID :one
+UPDATE auth_users
+SET is_active = $2
+WHERE id = $1
+RETURNING id, email, hashed_password, first_name, last_name, is_active, is_verified, created_at, updated_at
+`
+
+type SetUserActiveByIDParams struct {
+	ID       pgtype.UUID
+	IsActive bool
+}
+
+// activate or deactivate a user
+func (q *Queries) SetUserActiveByID(ctx context.Context, arg SetUserActiveByIDParams) (AuthUser, error) {
+	row := q.db.QueryRow(ctx, setUserActiveByID, arg.ID, arg.IsActive)
+	var i AuthUser
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsActive,
+		&i.IsVerified,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const setUserPasswordByEmail = `-- name: SetUserPasswordByEmail :exec
 UPDATE auth_users
 SET hashed_password = $2
@@ -113,3 +834,196 @@ func (q *Queries) SetUserPasswordByEmail(ctx context.Context, arg SetUserPasswor
 	_, err := q.db.Exec(ctx, setUserPasswordByEmail, arg.Email, arg.HashedPassword)
 	return err
 }
+
+const setUserPasswordByID = `-- name: SetUserPasswordByID :exec
+UPDATE auth_users
+SET hashed_password = $2
+WHERE id = $1
+`
+
+type SetUserPasswordByIDParams struct {
+	ID             pgtype.UUID
+	HashedPassword []byte
+}
+
+// set a user's password by their id
+func (q *Queries) SetUserPasswordByID(ctx context.Context, arg SetUserPasswordByIDParams) error {
+	_, err := q.db.Exec(ctx, setUserPasswordByID, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const setUserVerifiedByID = `-- name: SetUserVerifiedByID :exec
+UPDATE auth_users
+SET is_verified = true
+WHERE id = $1
+`
+
+// mark a user's email address as verified
+func (q *Queries) SetUserVerifiedByID(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, setUserVerifiedByID, id)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE auth_users
+SET email       = $2,
+    first_name  = $3,
+    last_name   = $4,
+    is_active   = $5,
+    is_verified = $6
+WHERE id = $1
+RETURNING id, email, hashed_password, first_name, last_name, is_active, is_verified, created_at, updated_at
+`
+
+type UpdateUserParams struct {
+	ID         pgtype.UUID
+	Email      string
+	FirstName  string
+	LastName   string
+	IsActive   bool
+	IsVerified bool
+}
+
+// update a user's details and status
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (AuthUser, error) {
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.ID,
+		arg.Email,
+		arg.FirstName,
+		arg.LastName,
+		arg.IsActive,
+		arg.IsVerified,
+	)
+	var i AuthUser
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.IsActive,
+		&i.IsVerified,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWebAuthnCredentialUse = `-- name: UpdateWebAuthnCredentialUse :exec
+UPDATE auth_webauthn_credentials
+SET sign_count   = $2,
+    backup_state = $3,
+    last_used_at = clock_timestamp()
+WHERE credential_id = $1
+`
+
+type UpdateWebAuthnCredentialUseParams struct {
+	CredentialID []byte
+	SignCount    int64
+	BackupState  bool
+}
+
+// record the use of a webauthn credential
+func (q *Queries) UpdateWebAuthnCredentialUse(ctx context.Context, arg UpdateWebAuthnCredentialUseParams) error {
+	_, err := q.db.Exec(ctx, updateWebAuthnCredentialUse, arg.CredentialID, arg.SignCount, arg.BackupState)
+	return err
+}
+
+const useEmailVerification = `-- name: UseEmailVerification :one
+DELETE
+FROM auth_email_verifications
+WHERE token_hash = $1
+  AND expires_at > clock_timestamp()
+RETURNING user_id
+`
+
+// delete an unexpired email verification returning its user id
+func (q *Queries) UseEmailVerification(ctx context.Context, tokenHash []byte) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, useEmailVerification, tokenHash)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const useInvite = `-- name: UseInvite :one
+UPDATE auth_invites
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND email = $2
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING id
+`
+
+type UseInviteParams struct {
+	TokenHash []byte
+	Email     string
+}
+
+// mark an unused and unexpired invite for the email address as used
+func (q *Queries) UseInvite(ctx context.Context, arg UseInviteParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, useInvite, arg.TokenHash, arg.Email)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :one
+UPDATE auth_password_resets
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING user_id
+`
+
+// mark an unused and unexpired password reset as used returning its user id
+func (q *Queries) UsePasswordReset(ctx context.Context, tokenHash []byte) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, usePasswordReset, tokenHash)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE auth_recovery_codes
+SET used_at = clock_timestamp()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING id
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   pgtype.UUID
+	CodeHash []byte
+}
+
+// mark an unused recovery code as used
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :one
+UPDATE auth_totp
+SET last_step = $2
+WHERE user_id = $1
+  AND last_step < $2
+RETURNING user_id
+`
+
+type UseTOTPStepParams struct {
+	UserID   pgtype.UUID
+	LastStep int64
+}
+
+// record the time step of an accepted code so it cannot be replayed
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, useTOTPStep, arg.UserID, arg.LastStep)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
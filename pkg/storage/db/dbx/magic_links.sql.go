@@ -0,0 +1,92 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: magic_links.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createMagicLink = `-- name: CreateMagicLink :one
+INSERT INTO auth_magic_links (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, expires_at, used_at, created_at
+`
+
+type CreateMagicLinkParams struct {
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+// create a new magic link token for a user
+func (q *Queries) CreateMagicLink(ctx context.Context, arg CreateMagicLinkParams) (AuthMagicLink, error) {
+	row := q.db.QueryRow(ctx, createMagicLink, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i AuthMagicLink
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMagicLinksByUserID = `-- name: DeleteMagicLinksByUserID :exec
+DELETE
+FROM auth_magic_links
+WHERE user_id = $1
+`
+
+// delete all magic links for a user
+func (q *Queries) DeleteMagicLinksByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMagicLinksByUserID, userID)
+	return err
+}
+
+const getMagicLink = `-- name: GetMagicLink :one
+SELECT id, user_id, token_hash, expires_at, used_at, created_at
+FROM auth_magic_links
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1
+`
+
+// get an unused and unexpired magic link by its token hash
+func (q *Queries) GetMagicLink(ctx context.Context, tokenHash []byte) (AuthMagicLink, error) {
+	row := q.db.QueryRow(ctx, getMagicLink, tokenHash)
+	var i AuthMagicLink
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useMagicLink = `-- name: UseMagicLink :one
+UPDATE auth_magic_links
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING user_id
+`
+
+// mark an unused and unexpired magic link as used returning its user id
+func (q *Queries) UseMagicLink(ctx context.Context, tokenHash []byte) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, useMagicLink, tokenHash)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
@@ -8,6 +8,138 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditEvent struct {
+	ID        int64
+	ActorID   pgtype.UUID
+	SubjectID pgtype.UUID
+	Action    string
+	Ip        string
+	UserAgent string
+	Metadata  []byte
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthAccessToken struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	Name       string
+	Prefix     string
+	TokenHash  []byte
+	Scopes     []string
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type AuthEmailVerification struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthIdentity struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	Provider  string
+	Subject   string
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthInvite struct {
+	ID        pgtype.UUID
+	Email     string
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthLockout struct {
+	UserID         pgtype.UUID
+	FailedAttempts int32
+	Lockouts       int32
+	LockedUntil    pgtype.Timestamptz
+	LastFailedAt   pgtype.Timestamptz
+}
+
+type AuthMagicLink struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthPasswordReset struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthPermission struct {
+	ID          pgtype.UUID
+	Name        string
+	Description string
+}
+
+type AuthRecoveryCode struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	CodeHash  []byte
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthRememberToken struct {
+	ID                    pgtype.UUID
+	UserID                pgtype.UUID
+	Selector              string
+	ValidatorHash         []byte
+	PreviousValidatorHash []byte
+	RotatedAt             pgtype.Timestamptz
+	ExpiresAt             pgtype.Timestamptz
+	CreatedAt             pgtype.Timestamptz
+}
+
+type AuthRole struct {
+	ID          pgtype.UUID
+	Name        string
+	Description string
+	CreatedAt   pgtype.Timestamptz
+}
+
+type AuthRolePermission struct {
+	RoleID       pgtype.UUID
+	PermissionID pgtype.UUID
+}
+
+type AuthSession struct {
+	ID               pgtype.UUID
+	TokenHash        []byte
+	UserID           pgtype.UUID
+	Data             []byte
+	ExpiresAt        pgtype.Timestamptz
+	CreatedIp        string
+	UserAgent        string
+	CreatedAt        pgtype.Timestamptz
+	LastSeenAt       pgtype.Timestamptz
+	RememberSelector pgtype.Text
+}
+
+type AuthTotp struct {
+	UserID    pgtype.UUID
+	Secret    []byte
+	LastStep  int64
+	EnabledAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
 type AuthUser struct {
 	ID             pgtype.UUID
 	Email          string
@@ -19,3 +151,55 @@ type AuthUser struct {
 	CreatedAt      pgtype.Timestamptz
 	UpdatedAt      pgtype.Timestamptz
 }
+
+type AuthUserRole struct {
+	UserID    pgtype.UUID
+	RoleID    pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type AuthWebauthnCredential struct {
+	ID              pgtype.UUID
+	UserID          pgtype.UUID
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Aaguid          []byte
+	SignCount       int64
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	Name            string
+	CreatedAt       pgtype.Timestamptz
+	LastUsedAt      pgtype.Timestamptz
+}
+
+type Membership struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+	Role           string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type Organization struct {
+	ID        pgtype.UUID
+	Name      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type OrganizationInvite struct {
+	ID             pgtype.UUID
+	OrganizationID pgtype.UUID
+	Email          string
+	Role           string
+	TokenHash      []byte
+	InvitedBy      pgtype.UUID
+	ExpiresAt      pgtype.Timestamptz
+	UsedAt         pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+}
+
+type RateLimit struct {
+	Key string
+	Tat pgtype.Timestamptz
+}
@@ -0,0 +1,405 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: organizations.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createMembership = `-- name: CreateMembership :exec
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING
+`
+
+type CreateMembershipParams struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+	Role           string
+}
+
+// add a user to an organization, keeping the role of an existing member
+func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipParams) error {
+	_, err := q.db.Exec(ctx, createMembership, arg.OrganizationID, arg.UserID, arg.Role)
+	return err
+}
+
+const createOrganization = `-- name: CreateOrganization :one
+INSERT INTO organizations (name)
+VALUES ($1)
+RETURNING id, name, created_at
+`
+
+// create a new organization
+func (q *Queries) CreateOrganization(ctx context.Context, name string) (Organization, error) {
+	row := q.db.QueryRow(ctx, createOrganization, name)
+	var i Organization
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const createOrganizationInvite = `-- name: CreateOrganizationInvite :one
+INSERT INTO organization_invites (organization_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, organization_id, email, role, token_hash, invited_by, expires_at, used_at, created_at
+`
+
+type CreateOrganizationInviteParams struct {
+	OrganizationID pgtype.UUID
+	Email          string
+	Role           string
+	TokenHash      []byte
+	InvitedBy      pgtype.UUID
+	ExpiresAt      pgtype.Timestamptz
+}
+
+// create a new invite to join an organization for an email address
+func (q *Queries) CreateOrganizationInvite(ctx context.Context, arg CreateOrganizationInviteParams) (OrganizationInvite, error) {
+	row := q.db.QueryRow(ctx, createOrganizationInvite,
+		arg.OrganizationID,
+		arg.Email,
+		arg.Role,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i OrganizationInvite
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMembership = `-- name: DeleteMembership :execrows
+DELETE
+FROM memberships
+WHERE organization_id = $1
+  AND user_id = $2
+`
+
+type DeleteMembershipParams struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+// remove a member from an organization
+func (q *Queries) DeleteMembership(ctx context.Context, arg DeleteMembershipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMembership, arg.OrganizationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOrganizationInvite = `-- name: DeleteOrganizationInvite :execrows
+DELETE
+FROM organization_invites
+WHERE id = $1
+  AND organization_id = $2
+`
+
+type DeleteOrganizationInviteParams struct {
+	ID             pgtype.UUID
+	OrganizationID pgtype.UUID
+}
+
+// revoke an invite of an organization
+func (q *Queries) DeleteOrganizationInvite(ctx context.Context, arg DeleteOrganizationInviteParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOrganizationInvite, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getMembership = `-- name: GetMembership :one
+SELECT organizations.id, organizations.name, organizations.created_at, memberships.role
+FROM organizations
+         JOIN memberships ON memberships.organization_id = organizations.id
+WHERE memberships.organization_id = $1
+  AND memberships.user_id = $2
+LIMIT 1
+`
+
+type GetMembershipParams struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+type GetMembershipRow struct {
+	Organization Organization
+	Role         string
+}
+
+// get an organization and the user's role in it, if they are a member
+func (q *Queries) GetMembership(ctx context.Context, arg GetMembershipParams) (GetMembershipRow, error) {
+	row := q.db.QueryRow(ctx, getMembership, arg.OrganizationID, arg.UserID)
+	var i GetMembershipRow
+	err := row.Scan(
+		&i.Organization.ID,
+		&i.Organization.Name,
+		&i.Organization.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getOrganizationInvite = `-- name: GetOrganizationInvite :one
+SELECT organization_invites.id, organization_invites.organization_id, organization_invites.email, organization_invites.role, organization_invites.token_hash, organization_invites.invited_by, organization_invites.expires_at, organization_invites.used_at, organization_invites.created_at, organizations.name AS organization_name
+FROM organization_invites
+         JOIN organizations ON organizations.id = organization_invites.organization_id
+WHERE organization_invites.token_hash = $1
+  AND organization_invites.used_at IS NULL
+  AND organization_invites.expires_at > clock_timestamp()
+LIMIT 1
+`
+
+type GetOrganizationInviteRow struct {
+	ID               pgtype.UUID
+	OrganizationID   pgtype.UUID
+	Email            string
+	Role             string
+	TokenHash        []byte
+	InvitedBy        pgtype.UUID
+	ExpiresAt        pgtype.Timestamptz
+	UsedAt           pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+	OrganizationName string
+}
+
+// get an unused and unexpired organization invite by its token hash, with the organization name
+func (q *Queries) GetOrganizationInvite(ctx context.Context, tokenHash []byte) (GetOrganizationInviteRow, error) {
+	row := q.db.QueryRow(ctx, getOrganizationInvite, tokenHash)
+	var i GetOrganizationInviteRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+		&i.OrganizationName,
+	)
+	return i, err
+}
+
+const listMembershipsByOrganizationID = `-- name: ListMembershipsByOrganizationID :many
+SELECT memberships.organization_id, memberships.user_id, memberships.role, memberships.created_at, auth_users.email, auth_users.first_name, auth_users.last_name
+FROM memberships
+         JOIN auth_users ON auth_users.id = memberships.user_id
+WHERE memberships.organization_id = $1
+ORDER BY auth_users.first_name, auth_users.last_name, auth_users.email
+`
+
+type ListMembershipsByOrganizationIDRow struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+	Role           string
+	CreatedAt      pgtype.Timestamptz
+	Email          string
+	FirstName      string
+	LastName       string
+}
+
+// list the members of an organization with their user details, by name
+func (q *Queries) ListMembershipsByOrganizationID(ctx context.Context, organizationID pgtype.UUID) ([]ListMembershipsByOrganizationIDRow, error) {
+	rows, err := q.db.Query(ctx, listMembershipsByOrganizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMembershipsByOrganizationIDRow{}
+	for rows.Next() {
+		var i ListMembershipsByOrganizationIDRow
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.UserID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.Email,
+			&i.FirstName,
+			&i.LastName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationInvites = `-- name: ListOrganizationInvites :many
+SELECT id, organization_id, email, role, token_hash, invited_by, expires_at, used_at, created_at
+FROM organization_invites
+WHERE organization_id = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+ORDER BY created_at DESC
+`
+
+// list the unused and unexpired invites of an organization, newest first
+func (q *Queries) ListOrganizationInvites(ctx context.Context, organizationID pgtype.UUID) ([]OrganizationInvite, error) {
+	rows, err := q.db.Query(ctx, listOrganizationInvites, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrganizationInvite{}
+	for rows.Next() {
+		var i OrganizationInvite
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Email,
+			&i.Role,
+			&i.TokenHash,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.UsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationsByUserID = `-- name: ListOrganizationsByUserID :many
+SELECT organizations.id, organizations.name, organizations.created_at, memberships.role
+FROM organizations
+         JOIN memberships ON memberships.organization_id = organizations.id
+WHERE memberships.user_id = $1
+ORDER BY organizations.name, organizations.created_at
+`
+
+type ListOrganizationsByUserIDRow struct {
+	Organization Organization
+	Role         string
+}
+
+// list the organizations a user is a member of with their role, by name
+func (q *Queries) ListOrganizationsByUserID(ctx context.Context, userID pgtype.UUID) ([]ListOrganizationsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listOrganizationsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrganizationsByUserIDRow{}
+	for rows.Next() {
+		var i ListOrganizationsByUserIDRow
+		if err := rows.Scan(
+			&i.Organization.ID,
+			&i.Organization.Name,
+			&i.Organization.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrganizationOwners = `-- name: LockOrganizationOwners :many
+SELECT user_id
+FROM memberships
+WHERE organization_id = $1
+  AND role = 'owner'
+FOR UPDATE
+`
+
+// get the owners of an organization, locking them until the transaction ends
+func (q *Queries) LockOrganizationOwners(ctx context.Context, organizationID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, lockOrganizationOwners, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMembershipRole = `-- name: UpdateMembershipRole :execrows
+UPDATE memberships
+SET role = $3
+WHERE organization_id = $1
+  AND user_id = $2
+`
+
+type UpdateMembershipRoleParams struct {
+	OrganizationID pgtype.UUID
+	UserID         pgtype.UUID
+	Role           string
+}
+
+// change a member's role in an organization
+func (q *Queries) UpdateMembershipRole(ctx context.Context, arg UpdateMembershipRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateMembershipRole, arg.OrganizationID, arg.UserID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useOrganizationInvite = `-- name: UseOrganizationInvite :one
+UPDATE organization_invites
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND email = $2
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING organization_id, role
+`
+
+type UseOrganizationInviteParams struct {
+	TokenHash []byte
+	Email     string
+}
+
+type UseOrganizationInviteRow struct {
+	OrganizationID pgtype.UUID
+	Role           string
+}
+
+// mark an unused and unexpired organization invite for the email address as used
+func (q *Queries) UseOrganizationInvite(ctx context.Context, arg UseOrganizationInviteParams) (UseOrganizationInviteRow, error) {
+	row := q.db.QueryRow(ctx, useOrganizationInvite, arg.TokenHash, arg.Email)
+	var i UseOrganizationInviteRow
+	err := row.Scan(&i.OrganizationID, &i.Role)
+	return i, err
+}
@@ -0,0 +1,76 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: rate_limits.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteExpiredRateLimits = `-- name: DeleteExpiredRateLimits :execrows
+DELETE
+FROM rate_limits
+WHERE tat < now()
+`
+
+// delete the limits that have fully recovered
+func (q *Queries) DeleteExpiredRateLimits(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRateLimits)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getRateLimit = `-- name: GetRateLimit :one
+SELECT tat, now()::timestamptz AS now
+FROM rate_limits
+WHERE key = $1
+`
+
+type GetRateLimitRow struct {
+	Tat pgtype.Timestamptz
+	Now pgtype.Timestamptz
+}
+
+// get the theoretical arrival time of the key's limit
+func (q *Queries) GetRateLimit(ctx context.Context, key string) (GetRateLimitRow, error) {
+	row := q.db.QueryRow(ctx, getRateLimit, key)
+	var i GetRateLimitRow
+	err := row.Scan(&i.Tat, &i.Now)
+	return i, err
+}
+
+const takeRateLimit = `-- name: TakeRateLimit :one
+INSERT INTO rate_limits AS r (key, tat)
+VALUES ($1, now() + make_interval(secs => $2::float8))
+ON CONFLICT (key) DO UPDATE
+    SET tat = greatest(r.tat, now()) + make_interval(secs => $2::float8)
+WHERE greatest(r.tat, now()) + make_interval(secs => $2::float8)
+          - make_interval(secs => $3::float8) <= now()
+RETURNING tat, now()::timestamptz AS now
+`
+
+type TakeRateLimitParams struct {
+	Key             string
+	IntervalSeconds float64
+	PeriodSeconds   float64
+}
+
+type TakeRateLimitRow struct {
+	Tat pgtype.Timestamptz
+	Now pgtype.Timestamptz
+}
+
+// take a request from the key's limit, moving its theoretical arrival time on by the interval.
+// returns no rows when the limit is exhausted, see GetRateLimit for when it allows the next request
+func (q *Queries) TakeRateLimit(ctx context.Context, arg TakeRateLimitParams) (TakeRateLimitRow, error) {
+	row := q.db.QueryRow(ctx, takeRateLimit, arg.Key, arg.IntervalSeconds, arg.PeriodSeconds)
+	var i TakeRateLimitRow
+	err := row.Scan(&i.Tat, &i.Now)
+	return i, err
+}
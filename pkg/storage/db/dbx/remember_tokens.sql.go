@@ -0,0 +1,166 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: remember_tokens.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRememberToken = `-- name: CreateRememberToken :one
+INSERT INTO auth_remember_tokens (user_id, selector, validator_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, selector, validator_hash, previous_validator_hash, rotated_at, expires_at, created_at
+`
+
+type CreateRememberTokenParams struct {
+	UserID        pgtype.UUID
+	Selector      string
+	ValidatorHash []byte
+	ExpiresAt     pgtype.Timestamptz
+}
+
+// create a new remember me token for a user
+func (q *Queries) CreateRememberToken(ctx context.Context, arg CreateRememberTokenParams) (AuthRememberToken, error) {
+	row := q.db.QueryRow(ctx, createRememberToken,
+		arg.UserID,
+		arg.Selector,
+		arg.ValidatorHash,
+		arg.ExpiresAt,
+	)
+	var i AuthRememberToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Selector,
+		&i.ValidatorHash,
+		&i.PreviousValidatorHash,
+		&i.RotatedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteOtherRememberTokensByUserID = `-- name: DeleteOtherRememberTokensByUserID :exec
+DELETE
+FROM auth_remember_tokens
+WHERE user_id = $1
+  AND selector <> $2
+`
+
+type DeleteOtherRememberTokensByUserIDParams struct {
+	UserID   pgtype.UUID
+	Selector string
+}
+
+// delete all remember me tokens for a user except the one given
+func (q *Queries) DeleteOtherRememberTokensByUserID(ctx context.Context, arg DeleteOtherRememberTokensByUserIDParams) error {
+	_, err := q.db.Exec(ctx, deleteOtherRememberTokensByUserID, arg.UserID, arg.Selector)
+	return err
+}
+
+const deleteRememberTokenBySelector = `-- name: DeleteRememberTokenBySelector :exec
+DELETE
+FROM auth_remember_tokens
+WHERE selector = $1
+`
+
+// delete a remember me token by its selector
+func (q *Queries) DeleteRememberTokenBySelector(ctx context.Context, selector string) error {
+	_, err := q.db.Exec(ctx, deleteRememberTokenBySelector, selector)
+	return err
+}
+
+const deleteRememberTokenBySessionID = `-- name: DeleteRememberTokenBySessionID :exec
+DELETE
+FROM auth_remember_tokens
+WHERE selector = (SELECT s.remember_selector
+                  FROM auth_sessions s
+                  WHERE s.id = $1
+                    AND s.user_id = $2)
+`
+
+type DeleteRememberTokenBySessionIDParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+// delete the remember me token one of a user's sessions was logged in with
+func (q *Queries) DeleteRememberTokenBySessionID(ctx context.Context, arg DeleteRememberTokenBySessionIDParams) error {
+	_, err := q.db.Exec(ctx, deleteRememberTokenBySessionID, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteRememberTokensByUserID = `-- name: DeleteRememberTokensByUserID :exec
+DELETE
+FROM auth_remember_tokens
+WHERE user_id = $1
+`
+
+// delete all remember me tokens for a user
+func (q *Queries) DeleteRememberTokensByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRememberTokensByUserID, userID)
+	return err
+}
+
+const getRememberTokenBySelector = `-- name: GetRememberTokenBySelector :one
+SELECT id, user_id, selector, validator_hash, previous_validator_hash, rotated_at, expires_at, created_at
+FROM auth_remember_tokens
+WHERE selector = $1
+  AND expires_at > clock_timestamp()
+LIMIT 1
+`
+
+// get an unexpired remember me token by its selector
+func (q *Queries) GetRememberTokenBySelector(ctx context.Context, selector string) (AuthRememberToken, error) {
+	row := q.db.QueryRow(ctx, getRememberTokenBySelector, selector)
+	var i AuthRememberToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Selector,
+		&i.ValidatorHash,
+		&i.PreviousValidatorHash,
+		&i.RotatedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const rotateRememberToken = `-- name: RotateRememberToken :execrows
+UPDATE auth_remember_tokens
+SET previous_validator_hash = validator_hash,
+    validator_hash          = $1,
+    rotated_at              = clock_timestamp(),
+    expires_at              = $2
+WHERE id = $3
+  AND validator_hash = $4
+`
+
+type RotateRememberTokenParams struct {
+	NewValidatorHash []byte
+	ExpiresAt        pgtype.Timestamptz
+	ID               pgtype.UUID
+	ValidatorHash    []byte
+}
+
+// replace the validator of a remember me token, keeping the previous one to detect its reuse.
+// nothing is updated if the validator was already rotated by another request.
+func (q *Queries) RotateRememberToken(ctx context.Context, arg RotateRememberTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rotateRememberToken,
+		arg.NewValidatorHash,
+		arg.ExpiresAt,
+		arg.ID,
+		arg.ValidatorHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,241 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: sessions.sql
+
+package dbx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSession = `-- name: CreateSession :exec
+INSERT INTO auth_sessions (token_hash, user_id, data, expires_at, created_ip, user_agent, remember_selector)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateSessionParams struct {
+	TokenHash        []byte
+	UserID           pgtype.UUID
+	Data             []byte
+	ExpiresAt        pgtype.Timestamptz
+	CreatedIp        string
+	UserAgent        string
+	RememberSelector pgtype.Text
+}
+
+// create a new server side session
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
+	_, err := q.db.Exec(ctx, createSession,
+		arg.TokenHash,
+		arg.UserID,
+		arg.Data,
+		arg.ExpiresAt,
+		arg.CreatedIp,
+		arg.UserAgent,
+		arg.RememberSelector,
+	)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE
+FROM auth_sessions
+WHERE expires_at <= clock_timestamp()
+`
+
+// delete all expired sessions
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOtherSessionsByUserID = `-- name: DeleteOtherSessionsByUserID :execrows
+DELETE
+FROM auth_sessions
+WHERE user_id = $1
+  AND token_hash <> $2
+`
+
+type DeleteOtherSessionsByUserIDParams struct {
+	UserID    pgtype.UUID
+	TokenHash []byte
+}
+
+// revoke all of a user's sessions except the one given
+func (q *Queries) DeleteOtherSessionsByUserID(ctx context.Context, arg DeleteOtherSessionsByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOtherSessionsByUserID, arg.UserID, arg.TokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSessionByIDAndUserID = `-- name: DeleteSessionByIDAndUserID :execrows
+DELETE
+FROM auth_sessions
+WHERE id = $1
+  AND user_id = $2
+`
+
+type DeleteSessionByIDAndUserIDParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+// revoke one of a user's sessions
+func (q *Queries) DeleteSessionByIDAndUserID(ctx context.Context, arg DeleteSessionByIDAndUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSessionByIDAndUserID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSessionByTokenHash = `-- name: DeleteSessionByTokenHash :execrows
+DELETE
+FROM auth_sessions
+WHERE token_hash = $1
+`
+
+// delete a session by its token hash
+func (q *Queries) DeleteSessionByTokenHash(ctx context.Context, tokenHash []byte) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSessionByTokenHash, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSessionsByUserID = `-- name: DeleteSessionsByUserID :execrows
+DELETE
+FROM auth_sessions
+WHERE user_id = $1
+`
+
+// revoke all sessions for a user
+func (q *Queries) DeleteSessionsByUserID(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSessionsByUserID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getSessionByTokenHash = `-- name: GetSessionByTokenHash :one
+SELECT id, token_hash, user_id, data, expires_at, created_ip, user_agent, created_at, last_seen_at, remember_selector
+FROM auth_sessions
+WHERE token_hash = $1
+  AND expires_at > clock_timestamp()
+LIMIT 1
+`
+
+// get an unexpired session by its token hash
+func (q *Queries) GetSessionByTokenHash(ctx context.Context, tokenHash []byte) (AuthSession, error) {
+	row := q.db.QueryRow(ctx, getSessionByTokenHash, tokenHash)
+	var i AuthSession
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.UserID,
+		&i.Data,
+		&i.ExpiresAt,
+		&i.CreatedIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.RememberSelector,
+	)
+	return i, err
+}
+
+const listSessionsByUserID = `-- name: ListSessionsByUserID :many
+SELECT id, token_hash, user_id, data, expires_at, created_ip, user_agent, created_at, last_seen_at, remember_selector
+FROM auth_sessions
+WHERE user_id = $1
+  AND expires_at > clock_timestamp()
+ORDER BY last_seen_at DESC
+`
+
+// list a user's unexpired sessions, most recently seen first
+func (q *Queries) ListSessionsByUserID(ctx context.Context, userID pgtype.UUID) ([]AuthSession, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuthSession{}
+	for rows.Next() {
+		var i AuthSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.UserID,
+			&i.Data,
+			&i.ExpiresAt,
+			&i.CreatedIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.RememberSelector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE auth_sessions
+SET last_seen_at = clock_timestamp()
+WHERE id = $1
+  AND last_seen_at < clock_timestamp() - interval '1 minute'
+`
+
+// record that a session was seen, at most once a minute
+func (q *Queries) TouchSession(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchSession, id)
+	return err
+}
+
+const updateSession = `-- name: UpdateSession :execrows
+UPDATE auth_sessions
+SET data              = $3,
+    expires_at        = $4,
+    remember_selector = $5,
+    last_seen_at      = clock_timestamp()
+WHERE token_hash = $1
+  AND user_id IS NOT DISTINCT FROM $2
+`
+
+type UpdateSessionParams struct {
+	TokenHash        []byte
+	UserID           pgtype.UUID
+	Data             []byte
+	ExpiresAt        pgtype.Timestamptz
+	RememberSelector pgtype.Text
+}
+
+// update a session's data and expiry, only while it is still for the same user
+func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSession,
+		arg.TokenHash,
+		arg.UserID,
+		arg.Data,
+		arg.ExpiresAt,
+		arg.RememberSelector,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
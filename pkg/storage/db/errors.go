@@ -0,0 +1,12 @@
+package db
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsUniqueViolation checks if the error is a postgres unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
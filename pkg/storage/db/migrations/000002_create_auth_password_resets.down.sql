@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_password_resets;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_email_verifications;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,14 @@
+begin;
+
+create table auth_email_verifications
+(
+    id         uuid                     default gen_random_uuid() not null primary key,
+    user_id    uuid                                               not null references auth_users (id) on delete cascade,
+    token_hash bytea                                              not null unique,
+    expires_at timestamp with time zone                           not null,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+create index auth_email_verifications_user_id_idx on auth_email_verifications (user_id);
+
+commit;
\ No newline at end of file
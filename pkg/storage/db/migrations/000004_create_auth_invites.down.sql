@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_invites;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,13 @@
+begin;
+
+create table auth_invites
+(
+    id         uuid                     default gen_random_uuid() not null primary key,
+    email      varchar(320)                                       not null,
+    token_hash bytea                                              not null unique,
+    expires_at timestamp with time zone                           not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+commit;
\ No newline at end of file
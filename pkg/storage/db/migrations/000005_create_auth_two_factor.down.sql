@@ -0,0 +1,6 @@
+begin;
+
+drop table auth_recovery_codes;
+drop table auth_totp;
+
+commit;
\ No newline at end of file
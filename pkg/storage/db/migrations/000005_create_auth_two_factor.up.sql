@@ -0,0 +1,23 @@
+begin;
+
+create table auth_totp
+(
+    user_id    uuid                                               not null primary key references auth_users (id) on delete cascade,
+    secret     bytea                                              not null,
+    last_step  bigint                   default 0                 not null,
+    enabled_at timestamp with time zone,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+create table auth_recovery_codes
+(
+    id         uuid                     default gen_random_uuid() not null primary key,
+    user_id    uuid                                               not null references auth_users (id) on delete cascade,
+    code_hash  bytea                                              not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+create index auth_recovery_codes_user_id_idx on auth_recovery_codes (user_id);
+
+commit;
\ No newline at end of file
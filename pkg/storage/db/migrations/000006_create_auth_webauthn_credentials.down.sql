@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_webauthn_credentials;
+
+commit;
\ No newline at end of file
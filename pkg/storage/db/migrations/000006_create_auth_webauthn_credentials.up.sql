@@ -0,0 +1,22 @@
+begin;
+
+create table auth_webauthn_credentials
+(
+    id               uuid                     default gen_random_uuid() not null primary key,
+    user_id          uuid                                               not null references auth_users (id) on delete cascade,
+    credential_id    bytea                                              not null unique,
+    public_key       bytea                                              not null,
+    attestation_type varchar(64)                                        not null,
+    aaguid           bytea                                              not null,
+    sign_count       bigint                   default 0                 not null,
+    transports       text[]                   default '{}'              not null,
+    backup_eligible  boolean                  default false             not null,
+    backup_state     boolean                  default false             not null,
+    name             varchar(120)                                       not null,
+    created_at       timestamp with time zone default clock_timestamp() not null,
+    last_used_at     timestamp with time zone
+);
+
+create index auth_webauthn_credentials_user_id_idx on auth_webauthn_credentials (user_id);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,15 @@
+begin;
+
+create table auth_identities
+(
+    id         uuid                     default gen_random_uuid() not null primary key,
+    user_id    uuid                                               not null references auth_users (id) on delete cascade,
+    provider   varchar(64)                                        not null,
+    subject    varchar(255)                                       not null,
+    created_at timestamp with time zone default clock_timestamp() not null,
+    unique (provider, subject)
+);
+
+create index auth_identities_user_id_idx on auth_identities (user_id);
+
+commit;
\ No newline at end of file
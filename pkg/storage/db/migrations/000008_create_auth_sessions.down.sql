@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_sessions;
+
+commit;
\ No newline at end of file
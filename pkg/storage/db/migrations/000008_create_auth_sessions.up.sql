@@ -0,0 +1,19 @@
+begin;
+
+create table auth_sessions
+(
+    id           uuid                     default gen_random_uuid() not null primary key,
+    token_hash   bytea                                              not null unique,
+    user_id      uuid references auth_users (id) on delete cascade,
+    data         bytea                                              not null,
+    expires_at   timestamp with time zone                           not null,
+    created_ip   varchar(64)                                        not null,
+    user_agent   text                                               not null,
+    created_at   timestamp with time zone default clock_timestamp() not null,
+    last_seen_at timestamp with time zone default clock_timestamp() not null
+);
+
+create index auth_sessions_user_id_idx on auth_sessions (user_id);
+create index auth_sessions_expires_at_idx on auth_sessions (expires_at);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,12 @@
+begin;
+
+create table auth_lockouts
+(
+    user_id         uuid                                               not null primary key references auth_users (id) on delete cascade,
+    failed_attempts integer                  default 0                 not null,
+    lockouts        integer                  default 0                 not null,
+    locked_until    timestamp with time zone,
+    last_failed_at  timestamp with time zone default clock_timestamp() not null
+);
+
+commit;
\ No newline at end of file
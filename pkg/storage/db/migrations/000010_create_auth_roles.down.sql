@@ -0,0 +1,8 @@
+begin;
+
+drop table auth_user_roles;
+drop table auth_role_permissions;
+drop table auth_permissions;
+drop table auth_roles;
+
+commit;
\ No newline at end of file
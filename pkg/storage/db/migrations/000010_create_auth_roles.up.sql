@@ -0,0 +1,48 @@
+begin;
+
+create table auth_roles
+(
+    id          uuid                     default gen_random_uuid() not null primary key,
+    name        varchar(64)                                        not null unique,
+    description text                     default ''                not null,
+    created_at  timestamp with time zone default clock_timestamp() not null
+);
+
+create table auth_permissions
+(
+    id          uuid default gen_random_uuid() not null primary key,
+    name        varchar(64)                    not null unique,
+    description text default ''                not null
+);
+
+create table auth_role_permissions
+(
+    role_id       uuid not null references auth_roles (id) on delete cascade,
+    permission_id uuid not null references auth_permissions (id) on delete cascade,
+    primary key (role_id, permission_id)
+);
+
+create table auth_user_roles
+(
+    user_id    uuid                                               not null references auth_users (id) on delete cascade,
+    role_id    uuid                                               not null references auth_roles (id) on delete cascade,
+    created_at timestamp with time zone default clock_timestamp() not null,
+    primary key (user_id, role_id)
+);
+
+create index auth_user_roles_role_id_idx on auth_user_roles (role_id);
+
+insert into auth_roles (name, description)
+values ('admin', 'Full access to the administration pages');
+
+insert into auth_permissions (name, description)
+values ('users.manage', 'Create, edit and deactivate users');
+
+insert into auth_role_permissions (role_id, permission_id)
+select r.id, p.id
+from auth_roles r,
+     auth_permissions p
+where r.name = 'admin'
+  and p.name = 'users.manage';
+
+commit;
\ No newline at end of file
@@ -0,0 +1,10 @@
+begin;
+
+delete
+from auth_permissions
+where name = 'audit.view';
+
+drop table audit_events;
+drop function audit_events_append_only();
+
+commit;
\ No newline at end of file
@@ -0,0 +1,44 @@
+begin;
+
+-- actor and subject have no foreign keys so events outlive the users they refer to
+create table audit_events
+(
+    id         bigint generated always as identity primary key,
+    actor_id   uuid,
+    subject_id uuid,
+    action     varchar(64)                                        not null,
+    ip         varchar(64)                                        not null,
+    user_agent text                                               not null,
+    metadata   jsonb                    default '{}'              not null,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+create index audit_events_actor_id_idx on audit_events (actor_id);
+create index audit_events_subject_id_idx on audit_events (subject_id);
+create index audit_events_action_idx on audit_events (action);
+
+create or replace function audit_events_append_only()
+    returns trigger as
+$$
+begin
+    raise exception 'audit_events is append-only';
+end;
+$$ language plpgsql;
+
+create trigger audit_events_append_only
+    before update or delete
+    on audit_events
+    for each row
+execute procedure audit_events_append_only();
+
+insert into auth_permissions (name, description)
+values ('audit.view', 'Browse the audit log');
+
+insert into auth_role_permissions (role_id, permission_id)
+select r.id, p.id
+from auth_roles r,
+     auth_permissions p
+where r.name = 'admin'
+  and p.name = 'audit.view';
+
+commit;
\ No newline at end of file
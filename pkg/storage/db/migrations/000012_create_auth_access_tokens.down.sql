@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_access_tokens;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,18 @@
+begin;
+
+create table auth_access_tokens
+(
+    id           uuid                     default gen_random_uuid() not null primary key,
+    user_id      uuid                                               not null references auth_users (id) on delete cascade,
+    name         varchar(120)                                       not null,
+    prefix       varchar(16)                                        not null,
+    token_hash   bytea                                              not null unique,
+    scopes       text[]                   default '{}'              not null,
+    expires_at   timestamp with time zone,
+    last_used_at timestamp with time zone,
+    created_at   timestamp with time zone default clock_timestamp() not null
+);
+
+create index auth_access_tokens_user_id_idx on auth_access_tokens (user_id);
+
+commit;
\ No newline at end of file
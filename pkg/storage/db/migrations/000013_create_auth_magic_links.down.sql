@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_magic_links;
+
+commit;
\ No newline at end of file
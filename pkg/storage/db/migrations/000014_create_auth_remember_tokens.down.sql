@@ -0,0 +1,5 @@
+begin;
+
+drop table auth_remember_tokens;
+
+commit;
\ No newline at end of file
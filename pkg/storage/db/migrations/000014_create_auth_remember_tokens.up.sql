@@ -0,0 +1,17 @@
+begin;
+
+create table auth_remember_tokens
+(
+    id                      uuid                     default gen_random_uuid() not null primary key,
+    user_id                 uuid                                               not null references auth_users (id) on delete cascade,
+    selector                varchar(32)                                        not null unique,
+    validator_hash          bytea                                              not null,
+    previous_validator_hash bytea,
+    rotated_at              timestamp with time zone,
+    expires_at              timestamp with time zone                           not null,
+    created_at              timestamp with time zone default clock_timestamp() not null
+);
+
+create index auth_remember_tokens_user_id_idx on auth_remember_tokens (user_id);
+
+commit;
\ No newline at end of file
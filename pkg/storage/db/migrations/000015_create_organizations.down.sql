@@ -0,0 +1,7 @@
+begin;
+
+drop table organization_invites;
+drop table memberships;
+drop table organizations;
+
+commit;
\ No newline at end of file
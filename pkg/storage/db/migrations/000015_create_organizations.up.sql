@@ -0,0 +1,36 @@
+begin;
+
+create table organizations
+(
+    id         uuid                     default gen_random_uuid() not null primary key,
+    name       varchar(120)                                       not null,
+    created_at timestamp with time zone default clock_timestamp() not null
+);
+
+create table memberships
+(
+    organization_id uuid                                               not null references organizations (id) on delete cascade,
+    user_id         uuid                                               not null references auth_users (id) on delete cascade,
+    role            varchar(32)              default 'member'          not null check (role in ('owner', 'admin', 'member')),
+    created_at      timestamp with time zone default clock_timestamp() not null,
+    primary key (organization_id, user_id)
+);
+
+create index memberships_user_id_idx on memberships (user_id);
+
+create table organization_invites
+(
+    id              uuid                     default gen_random_uuid() not null primary key,
+    organization_id uuid                                               not null references organizations (id) on delete cascade,
+    email           varchar(320)                                       not null,
+    role            varchar(32)              default 'member'          not null check (role in ('owner', 'admin', 'member')),
+    token_hash      bytea                                              not null unique,
+    invited_by      uuid                                               references auth_users (id) on delete set null,
+    expires_at      timestamp with time zone                           not null,
+    used_at         timestamp with time zone,
+    created_at      timestamp with time zone default clock_timestamp() not null
+);
+
+create index organization_invites_organization_id_idx on organization_invites (organization_id);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,5 @@
+begin;
+
+drop table if exists rate_limits;
+
+commit;
\ No newline at end of file
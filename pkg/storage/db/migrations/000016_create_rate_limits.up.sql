@@ -0,0 +1,11 @@
+begin;
+
+create unlogged table rate_limits
+(
+    key varchar(512)             not null primary key,
+    tat timestamp with time zone not null
+);
+
+create index rate_limits_tat_idx on rate_limits (tat);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,6 @@
+begin;
+
+alter table auth_sessions
+    drop column if exists remember_selector;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,6 @@
+begin;
+
+alter table auth_sessions
+    add column remember_selector varchar(32);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,34 @@
+-- name: CreateAccessToken :one
+-- create a new personal access token for a user
+INSERT INTO auth_access_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAccessTokenByHash :one
+-- get an unexpired access token by its hash
+SELECT *
+FROM auth_access_tokens
+WHERE token_hash = $1
+  AND (expires_at IS NULL OR expires_at > clock_timestamp())
+LIMIT 1;
+
+-- name: TouchAccessToken :exec
+-- record an access token as used, at most once a minute
+UPDATE auth_access_tokens
+SET last_used_at = clock_timestamp()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < clock_timestamp() - interval '1 minute');
+
+-- name: ListAccessTokensByUserID :many
+-- list a user's access tokens, newest first
+SELECT *
+FROM auth_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteAccessTokenByIDAndUserID :execrows
+-- revoke one of a user's access tokens
+DELETE
+FROM auth_access_tokens
+WHERE id = $1
+  AND user_id = $2;
\ No newline at end of file
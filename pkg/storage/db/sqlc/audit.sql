@@ -0,0 +1,34 @@
+-- name: CreateAuditEvent :exec
+-- append an event to the audit log
+INSERT INTO audit_events (actor_id, subject_id, action, ip, user_agent, metadata)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListAuditEvents :many
+-- list audit events newest first, filtered by action prefix, actor or subject email and time,
+-- paged by the id of the last event seen
+SELECT e.*,
+       coalesce(a.email, '')::text AS actor_email,
+       coalesce(s.email, '')::text AS subject_email
+FROM audit_events e
+         LEFT JOIN auth_users a ON a.id = e.actor_id
+         LEFT JOIN auth_users s ON s.id = e.subject_id
+WHERE e.action LIKE sqlc.arg(action)::text || '%'
+  AND (sqlc.arg(email)::text = '' OR a.email = sqlc.arg(email) OR s.email = sqlc.arg(email))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR e.created_at >= sqlc.narg(since))
+  AND (sqlc.arg(before_id)::bigint = 0 OR e.id < sqlc.arg(before_id))
+ORDER BY e.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListAuditEventsAfter :many
+-- list audit events oldest first after the id given, with the same filters as ListAuditEvents
+SELECT e.*,
+       coalesce(a.email, '')::text AS actor_email,
+       coalesce(s.email, '')::text AS subject_email
+FROM audit_events e
+         LEFT JOIN auth_users a ON a.id = e.actor_id
+         LEFT JOIN auth_users s ON s.id = e.subject_id
+WHERE e.action LIKE sqlc.arg(action)::text || '%'
+  AND (sqlc.arg(email)::text = '' OR a.email = sqlc.arg(email) OR s.email = sqlc.arg(email))
+  AND e.id > sqlc.arg(after_id)
+ORDER BY e.id
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
@@ -22,4 +22,308 @@ LIMIT 1;
 -- set a user's password
 UPDATE auth_users
 SET hashed_password = $2
-WHERE email = $1;
\ No newline at end of file
+WHERE email = $1;
+
+-- name: SetUserPasswordByID :exec
+-- set a user's password by their id
+UPDATE auth_users
+SET hashed_password = $2
+WHERE id = $1;
+
+-- name: CreatePasswordReset :one
+-- create a new password reset token for a user
+INSERT INTO auth_password_resets (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPasswordReset :one
+-- get an unused and unexpired password reset by its token hash
+SELECT *
+FROM auth_password_resets
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: UsePasswordReset :one
+-- mark an unused and unexpired password reset as used returning its user id
+UPDATE auth_password_resets
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING user_id;
+
+-- name: DeletePasswordResetsByUserID :exec
+-- delete all password resets for a user
+DELETE
+FROM auth_password_resets
+WHERE user_id = $1;
+
+-- name: SetUserVerifiedByID :exec
+-- mark a user's email address as verified
+UPDATE auth_users
+SET is_verified = true
+WHERE id = $1;
+
+-- name: CreateEmailVerification :one
+-- create a new email verification token for a user
+INSERT INTO auth_email_verifications (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UseEmailVerification :one
+-- delete an unexpired email verification returning its user id
+DELETE
+FROM auth_email_verifications
+WHERE token_hash = $1
+  AND expires_at > clock_timestamp()
+RETURNING user_id;
+
+-- name: DeleteEmailVerificationsByUserID :exec
+-- delete all email verifications for a user
+DELETE
+FROM auth_email_verifications
+WHERE user_id = $1;
+
+-- name: CreateInvite :one
+-- create a new registration invite for an email address
+INSERT INTO auth_invites (email, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetInvite :one
+-- get an unused and unexpired invite by its token hash
+SELECT *
+FROM auth_invites
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: UseInvite :one
+-- mark an unused and unexpired invite for the email address as used
+UPDATE auth_invites
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND email = $2
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING id;
+
+-- name: SetPendingTOTP :exec
+-- set a new totp secret for a user who has not yet enabled two factor
+INSERT INTO auth_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret     = excluded.secret,
+        last_step  = 0,
+        created_at = clock_timestamp()
+WHERE auth_totp.enabled_at IS NULL;
+
+-- name: GetTOTPByUserID :one
+-- get a user's totp secret
+SELECT *
+FROM auth_totp
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: EnableTOTP :exec
+-- enable two factor for a user
+UPDATE auth_totp
+SET enabled_at = clock_timestamp()
+WHERE user_id = $1;
+
+-- name: UseTOTPStep :one
+-- record the time step of an accepted code so it cannot be replayed
+UPDATE auth_totp
+SET last_step = $2
+WHERE user_id = $1
+  AND last_step < $2
+RETURNING user_id;
+
+-- name: DeleteTOTPByUserID :exec
+-- delete a user's totp secret disabling two factor
+DELETE
+FROM auth_totp
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+-- create a new two factor recovery code for a user
+INSERT INTO auth_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseRecoveryCode :one
+-- mark an unused recovery code as used
+UPDATE auth_recovery_codes
+SET used_at = clock_timestamp()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING id;
+
+-- name: CountRecoveryCodes :one
+-- count a user's unused recovery codes
+SELECT count(*)
+FROM auth_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesByUserID :exec
+-- delete all recovery codes for a user
+DELETE
+FROM auth_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateWebAuthnCredential :one
+-- create a new webauthn credential for a user
+INSERT INTO auth_webauthn_credentials (user_id, credential_id, public_key, attestation_type, aaguid, sign_count,
+                                       transports, backup_eligible, backup_state, name)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: ListWebAuthnCredentialsByUserID :many
+-- list a user's webauthn credentials
+SELECT *
+FROM auth_webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: UpdateWebAuthnCredentialUse :exec
+-- record the use of a webauthn credential
+UPDATE auth_webauthn_credentials
+SET sign_count   = $2,
+    backup_state = $3,
+    last_used_at = clock_timestamp()
+WHERE credential_id = $1;
+
+-- name: DeleteWebAuthnCredential :exec
+-- delete one of a user's webauthn credentials
+DELETE
+FROM auth_webauthn_credentials
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetUserByIdentity :one
+-- get the user linked to an identity provider subject
+SELECT auth_users.*
+FROM auth_users
+         JOIN auth_identities ON auth_identities.user_id = auth_users.id
+WHERE auth_identities.provider = $1
+  AND auth_identities.subject = $2
+LIMIT 1;
+
+-- name: CreateIdentity :exec
+-- link an identity provider subject to a user
+INSERT INTO auth_identities (user_id, provider, subject)
+VALUES ($1, $2, $3);
+
+-- name: RehashUserPassword :exec
+-- replace a user's password hash if it has not changed since it was read
+UPDATE auth_users
+SET hashed_password = sqlc.arg(new_hashed_password)
+WHERE id = sqlc.arg(id)
+  AND hashed_password = sqlc.arg(old_hashed_password);
+
+-- name: IsUserLocked :one
+-- check if a user is locked out of password login
+SELECT EXISTS (SELECT 1
+               FROM auth_lockouts
+               WHERE user_id = $1
+                 AND locked_until > clock_timestamp());
+
+-- name: RecordFailedLogin :one
+-- count a failed password login for a user
+INSERT INTO auth_lockouts (user_id, failed_attempts)
+VALUES ($1, 1)
+ON CONFLICT (user_id) DO UPDATE
+    SET failed_attempts = auth_lockouts.failed_attempts + 1,
+        last_failed_at  = clock_timestamp()
+RETURNING *;
+
+-- name: LockUser :exec
+-- lock a user out of password login until the time given
+UPDATE auth_lockouts
+SET failed_attempts = 0,
+    lockouts        = lockouts + 1,
+    locked_until    = $2
+WHERE user_id = $1;
+
+-- name: DeleteLockoutByUserID :exec
+-- clear a user's failed logins and lockouts
+DELETE
+FROM auth_lockouts
+WHERE user_id = $1;
+
+-- name: GetRoleByName :one
+-- get a role by name
+SELECT *
+FROM auth_roles
+WHERE name = $1;
+
+-- name: ListRoleNamesByUserID :many
+-- list the names of the roles granted to a user
+SELECT r.name
+FROM auth_roles r
+         JOIN auth_user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = $1
+ORDER BY r.name;
+
+-- name: ListPermissionNamesByUserID :many
+-- list the names of the permissions granted to a user through their roles
+SELECT DISTINCT p.name
+FROM auth_permissions p
+         JOIN auth_role_permissions rp ON rp.permission_id = p.id
+         JOIN auth_user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name;
+
+-- name: GrantUserRole :exec
+-- grant a role to a user
+INSERT INTO auth_user_roles (user_id, role_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeUserRole :execrows
+-- revoke a role from a user
+DELETE
+FROM auth_user_roles
+WHERE user_id = $1
+  AND role_id = $2;
+
+-- name: ListUsers :many
+-- list users whose email or name contains the search, ignoring case, ordered by email.
+-- strpos rather than ILIKE so % and _ in the search are matched literally
+SELECT *
+FROM auth_users
+WHERE sqlc.arg(search)::text = ''
+   OR strpos(lower(email), lower(sqlc.arg(search))) > 0
+   OR strpos(lower(first_name || ' ' || last_name), lower(sqlc.arg(search))) > 0
+ORDER BY email
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountUsers :one
+-- count users matching the search by email or name
+SELECT count(*)
+FROM auth_users
+WHERE sqlc.arg(search)::text = ''
+   OR strpos(lower(email), lower(sqlc.arg(search))) > 0
+   OR strpos(lower(first_name || ' ' || last_name), lower(sqlc.arg(search))) > 0;
+
+-- name: UpdateUser :one
+-- update a user's details and status
+UPDATE auth_users
+SET email       = $2,
+    first_name  = $3,
+    last_name   = $4,
+    is_active   = $5,
+    is_verified = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserActiveByID :one
+-- activate or deactivate a user
+UPDATE auth_users
+SET is_active = $2
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
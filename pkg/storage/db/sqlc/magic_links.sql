@@ -0,0 +1,29 @@
+-- name: CreateMagicLink :one
+-- create a new magic link token for a user
+INSERT INTO auth_magic_links (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetMagicLink :one
+-- get an unused and unexpired magic link by its token hash
+SELECT *
+FROM auth_magic_links
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: UseMagicLink :one
+-- mark an unused and unexpired magic link as used returning its user id
+UPDATE auth_magic_links
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING user_id;
+
+-- name: DeleteMagicLinksByUserID :exec
+-- delete all magic links for a user
+DELETE
+FROM auth_magic_links
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,100 @@
+-- name: CreateOrganization :one
+-- create a new organization
+INSERT INTO organizations (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: ListOrganizationsByUserID :many
+-- list the organizations a user is a member of with their role, by name
+SELECT sqlc.embed(organizations), memberships.role
+FROM organizations
+         JOIN memberships ON memberships.organization_id = organizations.id
+WHERE memberships.user_id = $1
+ORDER BY organizations.name, organizations.created_at;
+
+-- name: GetMembership :one
+-- get an organization and the user's role in it, if they are a member
+SELECT sqlc.embed(organizations), memberships.role
+FROM organizations
+         JOIN memberships ON memberships.organization_id = organizations.id
+WHERE memberships.organization_id = $1
+  AND memberships.user_id = $2
+LIMIT 1;
+
+-- name: CreateMembership :exec
+-- add a user to an organization, keeping the role of an existing member
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: ListMembershipsByOrganizationID :many
+-- list the members of an organization with their user details, by name
+SELECT memberships.*, auth_users.email, auth_users.first_name, auth_users.last_name
+FROM memberships
+         JOIN auth_users ON auth_users.id = memberships.user_id
+WHERE memberships.organization_id = $1
+ORDER BY auth_users.first_name, auth_users.last_name, auth_users.email;
+
+-- name: UpdateMembershipRole :execrows
+-- change a member's role in an organization
+UPDATE memberships
+SET role = $3
+WHERE organization_id = $1
+  AND user_id = $2;
+
+-- name: DeleteMembership :execrows
+-- remove a member from an organization
+DELETE
+FROM memberships
+WHERE organization_id = $1
+  AND user_id = $2;
+
+-- name: LockOrganizationOwners :many
+-- get the owners of an organization, locking them until the transaction ends
+SELECT user_id
+FROM memberships
+WHERE organization_id = $1
+  AND role = 'owner'
+FOR UPDATE;
+
+-- name: CreateOrganizationInvite :one
+-- create a new invite to join an organization for an email address
+INSERT INTO organization_invites (organization_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetOrganizationInvite :one
+-- get an unused and unexpired organization invite by its token hash, with the organization name
+SELECT organization_invites.*, organizations.name AS organization_name
+FROM organization_invites
+         JOIN organizations ON organizations.id = organization_invites.organization_id
+WHERE organization_invites.token_hash = $1
+  AND organization_invites.used_at IS NULL
+  AND organization_invites.expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: UseOrganizationInvite :one
+-- mark an unused and unexpired organization invite for the email address as used
+UPDATE organization_invites
+SET used_at = clock_timestamp()
+WHERE token_hash = $1
+  AND email = $2
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+RETURNING organization_id, role;
+
+-- name: ListOrganizationInvites :many
+-- list the unused and unexpired invites of an organization, newest first
+SELECT *
+FROM organization_invites
+WHERE organization_id = $1
+  AND used_at IS NULL
+  AND expires_at > clock_timestamp()
+ORDER BY created_at DESC;
+
+-- name: DeleteOrganizationInvite :execrows
+-- revoke an invite of an organization
+DELETE
+FROM organization_invites
+WHERE id = $1
+  AND organization_id = $2;
\ No newline at end of file
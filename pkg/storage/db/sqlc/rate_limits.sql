@@ -0,0 +1,22 @@
+-- name: TakeRateLimit :one
+-- take a request from the key's limit, moving its theoretical arrival time on by the interval.
+-- returns no rows when the limit is exhausted, see GetRateLimit for when it allows the next request
+INSERT INTO rate_limits AS r (key, tat)
+VALUES (sqlc.arg(key), now() + make_interval(secs => sqlc.arg(interval_seconds)::float8))
+ON CONFLICT (key) DO UPDATE
+    SET tat = greatest(r.tat, now()) + make_interval(secs => sqlc.arg(interval_seconds)::float8)
+WHERE greatest(r.tat, now()) + make_interval(secs => sqlc.arg(interval_seconds)::float8)
+          - make_interval(secs => sqlc.arg(period_seconds)::float8) <= now()
+RETURNING tat, now()::timestamptz AS now;
+
+-- name: GetRateLimit :one
+-- get the theoretical arrival time of the key's limit
+SELECT tat, now()::timestamptz AS now
+FROM rate_limits
+WHERE key = $1;
+
+-- name: DeleteExpiredRateLimits :execrows
+-- delete the limits that have fully recovered
+DELETE
+FROM rate_limits
+WHERE tat < now();
\ No newline at end of file
@@ -0,0 +1,52 @@
+-- name: CreateRememberToken :one
+-- create a new remember me token for a user
+INSERT INTO auth_remember_tokens (user_id, selector, validator_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetRememberTokenBySelector :one
+-- get an unexpired remember me token by its selector
+SELECT *
+FROM auth_remember_tokens
+WHERE selector = $1
+  AND expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: RotateRememberToken :execrows
+-- replace the validator of a remember me token, keeping the previous one to detect its reuse.
+-- nothing is updated if the validator was already rotated by another request.
+UPDATE auth_remember_tokens
+SET previous_validator_hash = validator_hash,
+    validator_hash          = @new_validator_hash,
+    rotated_at              = clock_timestamp(),
+    expires_at              = @expires_at
+WHERE id = @id
+  AND validator_hash = @validator_hash;
+
+-- name: DeleteRememberTokenBySelector :exec
+-- delete a remember me token by its selector
+DELETE
+FROM auth_remember_tokens
+WHERE selector = $1;
+
+-- name: DeleteRememberTokensByUserID :exec
+-- delete all remember me tokens for a user
+DELETE
+FROM auth_remember_tokens
+WHERE user_id = $1;
+
+-- name: DeleteOtherRememberTokensByUserID :exec
+-- delete all remember me tokens for a user except the one given
+DELETE
+FROM auth_remember_tokens
+WHERE user_id = $1
+  AND selector <> $2;
+
+-- name: DeleteRememberTokenBySessionID :exec
+-- delete the remember me token one of a user's sessions was logged in with
+DELETE
+FROM auth_remember_tokens
+WHERE selector = (SELECT s.remember_selector
+                  FROM auth_sessions s
+                  WHERE s.id = $1
+                    AND s.user_id = $2);
\ No newline at end of file
@@ -0,0 +1,69 @@
+-- name: CreateSession :exec
+-- create a new server side session
+INSERT INTO auth_sessions (token_hash, user_id, data, expires_at, created_ip, user_agent, remember_selector)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetSessionByTokenHash :one
+-- get an unexpired session by its token hash
+SELECT *
+FROM auth_sessions
+WHERE token_hash = $1
+  AND expires_at > clock_timestamp()
+LIMIT 1;
+
+-- name: UpdateSession :execrows
+-- update a session's data and expiry, only while it is still for the same user
+UPDATE auth_sessions
+SET data              = $3,
+    expires_at        = $4,
+    remember_selector = $5,
+    last_seen_at      = clock_timestamp()
+WHERE token_hash = $1
+  AND user_id IS NOT DISTINCT FROM $2;
+
+-- name: TouchSession :exec
+-- record that a session was seen, at most once a minute
+UPDATE auth_sessions
+SET last_seen_at = clock_timestamp()
+WHERE id = $1
+  AND last_seen_at < clock_timestamp() - interval '1 minute';
+
+-- name: DeleteSessionByTokenHash :execrows
+-- delete a session by its token hash
+DELETE
+FROM auth_sessions
+WHERE token_hash = $1;
+
+-- name: DeleteSessionsByUserID :execrows
+-- revoke all sessions for a user
+DELETE
+FROM auth_sessions
+WHERE user_id = $1;
+
+-- name: DeleteExpiredSessions :execrows
+-- delete all expired sessions
+DELETE
+FROM auth_sessions
+WHERE expires_at <= clock_timestamp();
+
+-- name: ListSessionsByUserID :many
+-- list a user's unexpired sessions, most recently seen first
+SELECT *
+FROM auth_sessions
+WHERE user_id = $1
+  AND expires_at > clock_timestamp()
+ORDER BY last_seen_at DESC;
+
+-- name: DeleteSessionByIDAndUserID :execrows
+-- revoke one of a user's sessions
+DELETE
+FROM auth_sessions
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: DeleteOtherSessionsByUserID :execrows
+-- revoke all of a user's sessions except the one given
+DELETE
+FROM auth_sessions
+WHERE user_id = $1
+  AND token_hash <> $2;
\ No newline at end of file
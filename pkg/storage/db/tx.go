@@ -0,0 +1,11 @@
+package db
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner begins a transaction, satisfied by both *pgx.Conn and *pgxpool.Pool.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter a Limiter keeping the limits in memory, for a single server.
+// At most maxKeys are kept, evicting the least recently used, and a key is dropped once its limit
+// has fully recovered, so memory stays bounded however many clients there are.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	maxKeys int
+	keys    map[string]*list.Element
+	lru     *list.List
+}
+
+// memoryEntry the theoretical arrival time of a key, when its limit has fully recovered.
+type memoryEntry struct {
+	key string
+	tat time.Time
+}
+
+// NewMemoryLimiter create a new MemoryLimiter keeping at most maxKeys.
+func NewMemoryLimiter(maxKeys int) *MemoryLimiter {
+	return &MemoryLimiter{
+		maxKeys: max(1, maxKeys),
+		keys:    map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Allow take a request from the key's limit.
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	tat := now
+	el, ok := m.keys[key]
+	if ok {
+		m.lru.MoveToFront(el)
+		tat = el.Value.(*memoryEntry).tat
+		if tat.Before(now) {
+			tat = now
+		}
+	}
+
+	next := tat.Add(limit.interval())
+	if next.Add(-limit.Period).After(now) {
+		return result(limit, tat, now, false), nil
+	}
+
+	if ok {
+		el.Value.(*memoryEntry).tat = next
+	} else {
+		m.keys[key] = m.lru.PushFront(&memoryEntry{key: key, tat: next})
+		m.evict(now)
+	}
+	return result(limit, next, now, true), nil
+}
+
+// evict drop the keys that have fully recovered from the back of the list,
+// then the least recently used while there are too many.
+func (m *MemoryLimiter) evict(now time.Time) {
+	for el := m.lru.Back(); el != nil; {
+		entry := el.Value.(*memoryEntry)
+		if len(m.keys) <= m.maxKeys && entry.tat.After(now) {
+			return
+		}
+		prev := el.Prev()
+		m.lru.Remove(el)
+		delete(m.keys, entry.key)
+		el = prev
+	}
+}
+
+// Sweep periodically drop the keys that have fully recovered until the context is cancelled.
+func (m *MemoryLimiter) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			now := time.Now()
+			for key, el := range m.keys {
+				if !el.Value.(*memoryEntry).tat.After(now) {
+					m.lru.Remove(el)
+					delete(m.keys, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
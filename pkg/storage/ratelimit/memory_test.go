@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// testLimit three requests an hour, recovering one every 20 minutes,
+// long enough that no time passes for the limit while a test runs.
+var testLimit = Limit{Requests: 3, Period: time.Hour}
+
+// within if the duration is want, less up to a second for the time the test has taken.
+func within(got, want time.Duration) bool {
+	return got <= want && got > want-time.Second
+}
+
+func TestMemoryLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryLimiter(10)
+
+	tests := []struct {
+		name           string
+		wantAllowed    bool
+		wantRemaining  int
+		wantReset      time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{"first", true, 2, 20 * time.Minute, 0},
+		{"second", true, 1, 40 * time.Minute, 0},
+		{"third", true, 0, time.Hour, 0},
+		{"burst exhausted", false, 0, time.Hour, 20 * time.Minute},
+		{"still exhausted", false, 0, time.Hour, 20 * time.Minute},
+	}
+	for _, tt := range tests {
+		r, err := m.Allow(ctx, "key", testLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Allowed != tt.wantAllowed || r.Remaining != tt.wantRemaining || r.Limit != testLimit.Requests {
+			t.Errorf("%s: allowed %v, remaining %d, limit %d, want %v, %d, %d", tt.name, r.Allowed, r.Remaining, r.Limit, tt.wantAllowed, tt.wantRemaining, testLimit.Requests)
+		}
+		if !within(r.Reset, tt.wantReset) {
+			t.Errorf("%s: reset %s, want %s", tt.name, r.Reset, tt.wantReset)
+		}
+		if tt.wantRetryAfter == 0 && r.RetryAfter != 0 || tt.wantRetryAfter != 0 && !within(r.RetryAfter, tt.wantRetryAfter) {
+			t.Errorf("%s: retry after %s, want %s", tt.name, r.RetryAfter, tt.wantRetryAfter)
+		}
+	}
+
+	if r, _ := m.Allow(ctx, "other", testLimit); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("other key: allowed %v, remaining %d, want its own limit", r.Allowed, r.Remaining)
+	}
+}
+
+func TestMemoryLimiterEvict(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		maxKeys  int
+		allow    []string
+		wantKeys []string
+	}{
+		{"within max keys", 3, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"least recently added", 2, []string{"a", "b", "c"}, []string{"b", "c"}},
+		{"least recently used", 2, []string{"a", "b", "a", "c"}, []string{"a", "c"}},
+		{"many past max keys", 2, []string{"a", "b", "c", "d", "e"}, []string{"d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryLimiter(tt.maxKeys)
+			for _, key := range tt.allow {
+				if _, err := m.Allow(ctx, key, testLimit); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(m.keys) != len(tt.wantKeys) || m.lru.Len() != len(tt.wantKeys) {
+				t.Errorf("kept %d keys and %d list entries, want %d", len(m.keys), m.lru.Len(), len(tt.wantKeys))
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := m.keys[key]; !ok {
+					t.Errorf("key %q evicted, want it kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterEvictRecovered(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryLimiter(10)
+
+	if _, err := m.Allow(ctx, "recovered", Limit{Requests: 1, Period: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := m.Allow(ctx, "limited", testLimit); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.keys["recovered"]; ok {
+		t.Error("the recovered key was kept, want it dropped")
+	}
+	if _, ok := m.keys["limited"]; !ok {
+		t.Error("the limited key was dropped, want it kept")
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+// PostgresLimiter a Limiter keeping the limits in postgres, shared by every server.
+// Each key is a single row updated atomically, rows are deleted by Sweep once their limit has fully recovered.
+type PostgresLimiter struct {
+	queries *dbx.Queries
+}
+
+// NewPostgresLimiter create a new PostgresLimiter.
+func NewPostgresLimiter(queries *dbx.Queries) *PostgresLimiter {
+	return &PostgresLimiter{queries: queries}
+}
+
+// Allow take a request from the key's limit.
+func (p *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	taken, err := p.queries.TakeRateLimit(ctx, dbx.TakeRateLimitParams{
+		Key:             key,
+		IntervalSeconds: limit.interval().Seconds(),
+		PeriodSeconds:   limit.Period.Seconds(),
+	})
+	if err == nil {
+		return result(limit, taken.Tat.Time, taken.Now.Time, true), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Result{}, err
+	}
+
+	current, err := p.queries.GetRateLimit(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, current.Tat.Time, current.Now.Time, false), nil
+}
+
+// Sweep periodically delete the limits that have fully recovered until the context is cancelled.
+func (p *PostgresLimiter) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := p.queries.DeleteExpiredRateLimits(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "rate limit sweep failed", slog.Any("error", err))
+				continue
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "rate limit sweep", slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
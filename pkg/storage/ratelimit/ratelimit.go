@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allow a number of requests in a period, recovering evenly over the period.
+// All the requests may be made at once, after that one more is allowed every period / requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// interval the time it takes to recover a single request.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(max(1, l.Requests))
+}
+
+// Result the outcome of taking a request from a limit.
+// Reset is how long until the limit has fully recovered,
+// RetryAfter how long until the next request is allowed when it is not.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter a rate limit backend, tracking each key's limit.
+type Limiter interface {
+	// Allow take a request from the key's limit.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result the result for a key whose theoretical arrival time, the time it fully recovers, is tat.
+// The limit is taken by moving tat on by the interval, allowed while that is within the period of now.
+// Not allowed results are given the current tat.
+func result(limit Limit, tat time.Time, now time.Time, allowed bool) Result {
+	interval := limit.interval()
+	r := Result{
+		Allowed: allowed,
+		Limit:   limit.Requests,
+		Reset:   max(0, tat.Sub(now)),
+	}
+	if allowed {
+		r.Remaining = int((limit.Period - r.Reset) / interval)
+	} else {
+		r.RetryAfter = max(0, tat.Add(interval-limit.Period).Sub(now))
+	}
+	return r
+}
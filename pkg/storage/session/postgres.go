@@ -0,0 +1,239 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/tokens"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// UserIDKey the session value holding the logged-in user's id,
+	// stored alongside the session so all sessions for a user can be revoked.
+	UserIDKey = "user_id"
+	// ImpersonatorIDKey the session value holding the id of the admin impersonating the logged-in user,
+	// the session is stored as the admin's so it is not listed or revoked as one of the user's.
+	ImpersonatorIDKey = "impersonator_id"
+	// RememberSelectorKey the session value holding the selector of the remember me token
+	// the session was logged in with, stored alongside the session so revoking it also revokes the token.
+	RememberSelectorKey = "remember_selector"
+	// browserSessionMaxAge how long a session lives on the server when
+	// the cookie has no max age and only lasts until the browser closes.
+	browserSessionMaxAge = 24 * time.Hour
+)
+
+// PostgresStore a sessions.Store keeping session data in postgres,
+// the cookie only holds a signed random token whose hash identifies the row.
+type PostgresStore struct {
+	queries *dbx.Queries
+	codecs  []securecookie.Codec
+	options *gsessions.Options
+}
+
+// NewPostgresStore create a new PostgresStore.
+// The key pairs are used to sign and optionally encrypt the session cookie.
+func NewPostgresStore(queries *dbx.Queries, keyPairs ...[]byte) *PostgresStore {
+	return &PostgresStore{
+		queries: queries,
+		codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		options: &gsessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+}
+
+// Options set the cookie options for new sessions.
+func (s *PostgresStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+// Get the cached session for the request, loading it if not yet cached.
+func (s *PostgresStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New load the session for the request, or a new empty session if there is not a valid one.
+func (s *PostgresStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err = securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	ctx := r.Context()
+	row, err := s.queries.GetSessionByTokenHash(ctx, tokens.Hash(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return session, nil
+		}
+		return session, err
+	}
+
+	if err = gob.NewDecoder(bytes.NewReader(row.Data)).Decode(&session.Values); err != nil {
+		return session, err
+	}
+	session.ID = token
+	session.IsNew = false
+
+	if err = s.queries.TouchSession(ctx, row.ID); err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// Save persist the session and write its cookie,
+// a negative max age deletes the session.
+func (s *PostgresStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	ctx := r.Context()
+
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if _, err := s.queries.DeleteSessionByTokenHash(ctx, tokens.Hash(session.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	var userID pgtype.UUID
+	if id, ok := session.Values[ImpersonatorIDKey].([16]byte); ok {
+		userID = pgtype.UUID{Bytes: id, Valid: true}
+	} else if id, ok := session.Values[UserIDKey].([16]byte); ok {
+		userID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	var rememberSelector pgtype.Text
+	if selector, ok := session.Values[RememberSelectorKey].(string); ok {
+		rememberSelector = pgtype.Text{String: selector, Valid: true}
+	}
+	maxAge := time.Duration(session.Options.MaxAge) * time.Second
+	if maxAge == 0 {
+		maxAge = browserSessionMaxAge
+	}
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(maxAge), Valid: true}
+
+	if session.ID != "" {
+		data, err := encode(session.Values)
+		if err != nil {
+			return err
+		}
+		updated, err := s.queries.UpdateSession(ctx, dbx.UpdateSessionParams{
+			TokenHash:        tokens.Hash(session.ID),
+			UserID:           userID,
+			Data:             data,
+			ExpiresAt:        expiresAt,
+			RememberSelector: rememberSelector,
+		})
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			// either the user changed, e.g. logging in, so the token is replaced to prevent session fixation,
+			// or the session was revoked since it was loaded and must not be brought back by this request.
+			deleted, err := s.queries.DeleteSessionByTokenHash(ctx, tokens.Hash(session.ID))
+			if err != nil {
+				return err
+			}
+			if deleted == 0 {
+				clear(session.Values)
+				userID = pgtype.UUID{}
+				rememberSelector = pgtype.Text{}
+			}
+			session.ID = ""
+		}
+	}
+
+	if session.ID == "" {
+		data, err := encode(session.Values)
+		if err != nil {
+			return err
+		}
+		token, hash, err := tokens.Generate()
+		if err != nil {
+			return err
+		}
+		if err = s.queries.CreateSession(ctx, dbx.CreateSessionParams{
+			TokenHash:        hash,
+			UserID:           userID,
+			Data:             data,
+			ExpiresAt:        expiresAt,
+			CreatedIp:        clientIP(r),
+			UserAgent:        r.UserAgent(),
+			RememberSelector: rememberSelector,
+		}); err != nil {
+			return err
+		}
+		session.ID = token
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Sweep periodically delete expired sessions until the context is cancelled.
+func (s *PostgresStore) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.queries.DeleteExpiredSessions(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "session sweep failed", slog.Any("error", err))
+				continue
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "session sweep", slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// encode the session values for storage.
+func encode(values map[any]any) ([]byte, error) {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(values); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// clientIP the address of the client that created the session, for display only.
+// It is gin's client ip, respecting the trusted proxies, when the gin context is on the request's context.
+func clientIP(r *http.Request) string {
+	if c, ok := r.Context().Value(gin.ContextKey).(*gin.Context); ok {
+		return c.ClientIP()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
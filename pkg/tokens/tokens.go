@@ -0,0 +1,31 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Generate generate a random url safe token and its sha256 hash for storage.
+func Generate() (string, []byte, error) {
+	token, err := Random(32)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, Hash(token), nil
+}
+
+// Random a random url safe string of n bytes, for values that are not stored hashed, e.g. the oauth2 state.
+func Random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash hash a token for storage or lookup, only the hash is stored so a leaked table cannot be used to log in.
+func Hash(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+	return h[:]
+}
@@ -0,0 +1,156 @@
+package appctx
+
+import (
+	"context"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/storage/ratelimit"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/htmx"
+	"github.com/a-h/templ"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Gin get the gin context of the request, either the context itself or one derived from the request's.
+// The accessors below use it, so templ components and services can use them too, for the duration of the request.
+func Gin(ctx context.Context) (*gin.Context, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c, true
+	}
+	c, ok := ctx.Value(gin.ContextKey).(*gin.Context)
+	return c, ok
+}
+
+// Queries get the database queries.
+func Queries(ctx context.Context) *dbx.Queries {
+	return mustGet[*dbx.Queries](ctx, ctxkey.Queries)
+}
+
+// Postgres get the database pool, for beginning transactions.
+func Postgres(ctx context.Context) *pgxpool.Pool {
+	return mustGet[*pgxpool.Pool](ctx, ctxkey.Postgres)
+}
+
+// Session get the session.
+func Session(ctx context.Context) sessions.Session {
+	return mustGet[sessions.Session](ctx, ctxkey.Session)
+}
+
+// HTMX get the HTMX request and response helpers.
+func HTMX(ctx context.Context) *htmx.HTMX {
+	return mustGet[*htmx.HTMX](ctx, ctxkey.HTMX)
+}
+
+// CurrentUser get the logged-in user, if any. Only routes behind the auth middleware,
+// or those that loaded the user with middleware.CurrentUser, have one.
+func CurrentUser(ctx context.Context) (dbx.AuthUser, bool) {
+	return get[dbx.AuthUser](ctx, ctxkey.User)
+}
+
+// MustCurrentUser get the logged-in user on routes behind the auth middleware, panics without one.
+func MustCurrentUser(ctx context.Context) dbx.AuthUser {
+	return mustGet[dbx.AuthUser](ctx, ctxkey.User)
+}
+
+// Impersonator get the admin impersonating the logged-in user, if any.
+// Like CurrentUser it is only set once the user is loaded.
+func Impersonator(ctx context.Context) (dbx.AuthUser, bool) {
+	return get[dbx.AuthUser](ctx, ctxkey.Impersonator)
+}
+
+// AccessToken get the access token of a request authenticated by middleware.BearerAuth, if any.
+func AccessToken(ctx context.Context) (dbx.AuthAccessToken, bool) {
+	return get[dbx.AuthAccessToken](ctx, ctxkey.AccessToken)
+}
+
+// Permissions get the logged-in user's permission names if they have been loaded,
+// middleware.Permissions loads them.
+func Permissions(ctx context.Context) ([]string, bool) {
+	return get[[]string](ctx, ctxkey.Permissions)
+}
+
+// Org get the current organization, if the user has one, on routes behind middleware.CurrentOrg.
+func Org(ctx context.Context) (dbx.Organization, bool) {
+	return get[dbx.Organization](ctx, ctxkey.Org)
+}
+
+// MustOrg get the current organization on routes behind middleware.RequireOrg, panics without one.
+func MustOrg(ctx context.Context) dbx.Organization {
+	return mustGet[dbx.Organization](ctx, ctxkey.Org)
+}
+
+// OrgRole get the logged-in user's role in the current organization, empty without one.
+func OrgRole(ctx context.Context) string {
+	role, _ := get[string](ctx, ctxkey.OrgRole)
+	return role
+}
+
+// RateLimiter get the rate limit backend.
+func RateLimiter(ctx context.Context) ratelimit.Limiter {
+	return mustGet[ratelimit.Limiter](ctx, ctxkey.RateLimiter)
+}
+
+// RateLimits get the rate limit config.
+func RateLimits(ctx context.Context) config.RateLimitConfig {
+	return mustGet[config.RateLimitConfig](ctx, ctxkey.RateLimits)
+}
+
+// RememberMe get the remember me config and the session config its cookie shares, if it is enabled.
+func RememberMe(ctx context.Context) (config.RememberMeConfig, config.SessionConfig, bool) {
+	cfg, ok := get[config.RememberMeConfig](ctx, ctxkey.RememberMe)
+	if !ok {
+		return config.RememberMeConfig{}, config.SessionConfig{}, false
+	}
+	return cfg, mustGet[config.SessionConfig](ctx, ctxkey.RememberMeSession), true
+}
+
+// OOB get the components added to swap out of band in the response, if any.
+func OOB(ctx context.Context) []templ.Component {
+	components, _ := get[[]templ.Component](ctx, ctxkey.OOB)
+	return components
+}
+
+// HasCSRF check if the request is behind the CSRF middleware, so it has a token for its forms.
+func HasCSRF(ctx context.Context) bool {
+	_, ok := get[string](ctx, ctxkey.CSRFSecret)
+	return ok
+}
+
+// get get the value of the key, not ok if the request has none.
+// It panics if the value has the wrong type, as its key is misused.
+func get[T any](ctx context.Context, key string) (T, bool) {
+	var zero T
+	c, ok := Gin(ctx)
+	if !ok {
+		return zero, false
+	}
+	value, ok := c.Get(key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		panic("appctx: the request's " + key + " has the wrong type")
+	}
+	return typed, true
+}
+
+// mustGet get the value of the key, panics if the request has no value of the type,
+// meaning the middleware setting it is missing.
+func mustGet[T any](ctx context.Context, key string) T {
+	c, ok := Gin(ctx)
+	if !ok {
+		panic("appctx: the context is not from a request")
+	}
+	value, ok := c.Get(key)
+	if !ok {
+		panic("appctx: the request has no " + key + ", is its middleware missing?")
+	}
+	typed, ok := value.(T)
+	if !ok {
+		panic("appctx: the request's " + key + " has the wrong type")
+	}
+	return typed
+}
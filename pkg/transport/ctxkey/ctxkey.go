@@ -0,0 +1,38 @@
+package ctxkey
+
+// The keys of the request's values the middleware set on the gin context,
+// shared by the middleware setting them, the appctx accessors and anything else reading them.
+const (
+	// HTMX the HTMX request and response helpers, set by middleware.Context.
+	HTMX = "htmx"
+	// Postgres the database pool, set by middleware.Context.
+	Postgres = "postgres"
+	// Queries the database queries, set by middleware.Context.
+	Queries = "queries"
+	// Session the session, set by middleware.Context.
+	Session = "session"
+	// User the logged-in user, set by the auth middleware or BearerAuth.
+	User = "user"
+	// Impersonator the admin impersonating the logged-in user, set by the auth middleware.
+	Impersonator = "impersonator"
+	// AccessToken the access token of a request authenticated by BearerAuth.
+	AccessToken = "access_token"
+	// Permissions the logged-in user's permission names, loaded on first use.
+	Permissions = "permissions"
+	// Org the current organization, set by middleware.CurrentOrg.
+	Org = "org"
+	// OrgRole the logged-in user's role in the current organization, set by middleware.CurrentOrg.
+	OrgRole = "org_role"
+	// RateLimiter the rate limit backend, set by middleware.RateLimits.
+	RateLimiter = "rate_limiter"
+	// RateLimits the rate limit config, set by middleware.RateLimits.
+	RateLimits = "rate_limits"
+	// RememberMe the remember me config, set by middleware.RememberMe when it is enabled.
+	RememberMe = "remember_me"
+	// RememberMeSession the session config its cookie shares, set alongside RememberMe.
+	RememberMeSession = "remember_me_session"
+	// OOB the components to swap out of band, added by html.AddOOB.
+	OOB = "html_oob"
+	// CSRFSecret the CSRF secret, set by the gin-csrf middleware on the routes it protects.
+	CSRFSecret = "csrfSecret"
+)
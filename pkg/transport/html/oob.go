@@ -0,0 +1,28 @@
+package html
+
+import (
+	"context"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"io"
+)
+
+// AddOOB add components to swap out of band in the response to an HTMX request,
+// so one response can also update other parts of the page, e.g. the header.
+// Each component's root element needs an id and `hx-swap-oob="true"`.
+// They are not rendered for other requests, whose full page already has them.
+func AddOOB(c *gin.Context, components ...templ.Component) {
+	c.Set(ctxkey.OOB, append(appctx.OOB(c), components...))
+}
+
+// renderOOB render the out of band swaps added to the request after its fragment.
+func renderOOB(ctx context.Context, c *gin.Context, w io.Writer) error {
+	for _, component := range appctx.OOB(c) {
+		if err := component.Render(ctx, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,19 +2,28 @@ package html
 
 import (
 	"context"
+	"errors"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/middleware"
+	"gin.go.dev/pkg/transport/respond"
+	"gin.go.dev/pkg/ui/ctxutil"
+	"gin.go.dev/pkg/ui/layouts"
 	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/render"
+	csrf "github.com/stuartaccent/gin-csrf"
 	"net/http"
 )
 
-type Render struct {
+// Renderer the engine's HTML renderer. Templ components are rendered with the request by Render,
+// they fail through c.HTML as gin renders without the request.
+type Renderer struct {
 	Fallback render.HTMLRender
 }
 
-func (r *Render) Instance(s string, d any) render.Render {
+func (r *Renderer) Instance(s string, d any) render.Render {
 	if component, ok := d.(templ.Component); ok {
 		return &TemplRender{
-			Ctx:       context.Background(),
 			Status:    -1,
 			Component: component,
 		}
@@ -23,22 +32,93 @@ func (r *Render) Instance(s string, d any) render.Render {
 }
 
 type TemplRender struct {
-	Ctx       context.Context
 	Status    int
 	Component templ.Component
+	c         *gin.Context
 }
 
+// errNoRequest the component was rendered without its request, through c.HTML rather than Render,
+// without it the components would render without the request's user, CSRF token or fragments.
+var errNoRequest = errors.New("html: rendering without the request, render templ components with html.Render")
+
+// Render respond with the component, rendered with the request.
+func Render(c *gin.Context, status int, component templ.Component) {
+	c.Render(status, TemplRender{
+		Status:    -1,
+		Component: component,
+		c:         c,
+	})
+}
+
+// Negotiate respond with the data as JSON or the component as HTML, whichever is wanted.
+// The component is only built for HTML so it can use what only the HTML routes have, e.g. the CSRF token.
+func Negotiate(c *gin.Context, status int, data any, component func() templ.Component) {
+	if respond.WantsJSON(c) {
+		respond.Data(c, status, data)
+		return
+	}
+	Render(c, status, component())
+}
+
+// Render the component with the request's context. HTMX requests that are not boosted or restoring history get only the page's
+// fragment with the id of the targeted element, if it has one, followed by any out of band swaps.
+// Other requests get the full page.
 func (r TemplRender) Render(w http.ResponseWriter) error {
+	c := r.c
+	if c == nil {
+		return errNoRequest
+	}
+
 	r.WriteContentType(w)
+	appctx.HTMX(c).Vary()
+	ctx := renderContext(c)
+
 	if r.Status != -1 {
 		w.WriteHeader(r.Status)
 	}
-	if r.Component != nil {
-		return r.Component.Render(r.Ctx, w)
+	if r.Component == nil {
+		return nil
+	}
+
+	if !isPartial(c) {
+		return r.Component.Render(ctx, w)
+	}
+
+	found := false
+	if target := appctx.HTMX(c).Target(); target != "" {
+		var err error
+		if found, err = layouts.RenderFragment(ctx, r.Component, target, w); err != nil {
+			return err
+		}
+	}
+	if !found {
+		if err := r.Component.Render(ctx, w); err != nil {
+			return err
+		}
 	}
-	return nil
+	return renderOOB(ctx, c, w)
 }
 
 func (r TemplRender) WriteContentType(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 }
+
+// renderContext the request's context with the values components use, see ctxutil.
+// They are read before the response is written, as the CSRF token may need saving to the session.
+func renderContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if user, ok := appctx.CurrentUser(c); ok {
+		ctx = ctxutil.WithUser(ctx, user, middleware.Permissions(c))
+	}
+	if appctx.HasCSRF(c) {
+		ctx = ctxutil.WithCSRF(ctx, csrf.GetToken(c))
+	}
+	return ctx
+}
+
+// isPartial check if the request is from HTMX swapping part of the page,
+// rather than a boosted link or history restoration that need the full page.
+func isPartial(c *gin.Context) bool {
+	hx := appctx.HTMX(c)
+	return hx.IsHTMXRequest() && !hx.IsBoosted() && !hx.IsHistoryRestoreRequest()
+}
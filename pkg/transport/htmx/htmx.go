@@ -0,0 +1,189 @@
+package htmx
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HTMX contains utilities for dealing with HTMX requests
+type HTMX struct {
+	Request  *http.Request
+	Response http.ResponseWriter
+	events   map[string]Events
+}
+
+// Events the client side events to trigger, by name with their detail, nil when there is none.
+// The detail is sent as JSON, e.g. Events{"saved": nil, "notify": map[string]any{"level": "info"}}.
+type Events map[string]any
+
+// Location the `HX-Location` spec, a client side redirect that does not reload the page.
+// Only the path is required, the rest default to their HTMX behaviour when empty.
+type Location struct {
+	Path    string            `json:"path"`
+	Source  string            `json:"source,omitempty"`
+	Event   string            `json:"event,omitempty"`
+	Handler string            `json:"handler,omitempty"`
+	Target  string            `json:"target,omitempty"`
+	Swap    string            `json:"swap,omitempty"`
+	Values  map[string]any    `json:"values,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Select  string            `json:"select,omitempty"`
+}
+
+// IsHTMXRequest checks if the request is an HTMX request.
+func (h *HTMX) IsHTMXRequest() bool {
+	return h.Request.Header.Get("HX-Request") == "true"
+}
+
+// IsBoosted checks if the request is from an element using `hx-boost`.
+func (h *HTMX) IsBoosted() bool {
+	return h.Request.Header.Get("HX-Boosted") == "true"
+}
+
+// IsHistoryRestoreRequest checks if the request is for history restoration after a miss in the local history cache.
+func (h *HTMX) IsHistoryRestoreRequest() bool {
+	return h.Request.Header.Get("HX-History-Restore-Request") == "true"
+}
+
+// CurrentURL gets the current url of the browser from `HX-Current-URL`.
+func (h *HTMX) CurrentURL() string {
+	return h.Request.Header.Get("HX-Current-URL")
+}
+
+// Prompt gets the user response to an `hx-prompt` from `HX-Prompt`.
+func (h *HTMX) Prompt() string {
+	return h.Request.Header.Get("HX-Prompt")
+}
+
+// Target gets the id of the target element from `HX-Target`, if it has one.
+func (h *HTMX) Target() string {
+	return h.Request.Header.Get("HX-Target")
+}
+
+// Trigger gets the id of the triggered element from `HX-Trigger`, if it has one.
+func (h *HTMX) Trigger() string {
+	return h.Request.Header.Get("HX-Trigger")
+}
+
+// TriggerName gets the name of the triggered element from `HX-Trigger-Name`, if it has one.
+func (h *HTMX) TriggerName() string {
+	return h.Request.Header.Get("HX-Trigger-Name")
+}
+
+// Vary adds `HX-Request` to the `Vary` header, for responses that differ for HTMX requests
+// so caches keep the full page and the partial apart.
+func (h *HTMX) Vary() {
+	for _, v := range h.Response.Header().Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "HX-Request") {
+				return
+			}
+		}
+	}
+	h.Response.Header().Add("Vary", "HX-Request")
+}
+
+// SetRedirect sets the `HX-Redirect` header for HTMX requests.
+func (h *HTMX) SetRedirect(url string) {
+	h.Response.Header().Set("HX-Redirect", url)
+}
+
+// SetLocation sets the `HX-Location` header for HTMX requests, to load the path without a page reload.
+func (h *HTMX) SetLocation(path string) {
+	h.Response.Header().Set("HX-Location", path)
+}
+
+// SetLocationSpec sets the `HX-Location` header for HTMX requests from the spec,
+// to load the path into a target or with values without a page reload.
+func (h *HTMX) SetLocationSpec(location Location) error {
+	b, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+	h.Response.Header().Set("HX-Location", string(b))
+	return nil
+}
+
+// SetPushUrl sets the `HX-Push-Url` header for HTMX requests.
+func (h *HTMX) SetPushUrl(url string) {
+	h.Response.Header().Set("HX-Push-Url", url)
+}
+
+// SetReplaceUrl sets the `HX-Replace-Url` header for HTMX requests, replacing the current url in the history.
+func (h *HTMX) SetReplaceUrl(url string) {
+	h.Response.Header().Set("HX-Replace-Url", url)
+}
+
+// SetRefresh sets the `HX-Refresh` header for HTMX requests.
+func (h *HTMX) SetRefresh() {
+	h.Response.Header().Set("HX-Refresh", "true")
+}
+
+// SetReswap sets the `HX-Reswap` header for HTMX requests, overriding how the response is swapped,
+// e.g. "outerHTML" or "innerHTML show:top".
+func (h *HTMX) SetReswap(swap string) {
+	h.Response.Header().Set("HX-Reswap", swap)
+}
+
+// SetRetarget sets the `HX-Retarget` header for HTMX requests, a css selector overriding the target.
+func (h *HTMX) SetRetarget(selector string) {
+	h.Response.Header().Set("HX-Retarget", selector)
+}
+
+// SetReselect sets the `HX-Reselect` header for HTMX requests, a css selector choosing the part of the response to swap.
+func (h *HTMX) SetReselect(selector string) {
+	h.Response.Header().Set("HX-Reselect", selector)
+}
+
+// SetTrigger sets the `HX-Trigger` header for HTMX requests, triggering the events once the response is received.
+// Events from earlier calls are kept, an event triggered again has its detail replaced.
+func (h *HTMX) SetTrigger(events Events) error {
+	return h.setEvents("HX-Trigger", events)
+}
+
+// SetTriggerAfterSettle sets the `HX-Trigger-After-Settle` header for HTMX requests,
+// triggering the events after the settle step.
+func (h *HTMX) SetTriggerAfterSettle(events Events) error {
+	return h.setEvents("HX-Trigger-After-Settle", events)
+}
+
+// SetTriggerAfterSwap sets the `HX-Trigger-After-Swap` header for HTMX requests,
+// triggering the events after the swap step.
+func (h *HTMX) SetTriggerAfterSwap(events Events) error {
+	return h.setEvents("HX-Trigger-After-Swap", events)
+}
+
+// setEvents merge the events into those already set on the header, then set it.
+// Events without any detail are sent as a list of names, otherwise as a JSON object.
+// The merged events are only kept once the header is set, so a failed call changes nothing.
+func (h *HTMX) setEvents(header string, events Events) error {
+	merged := Events{}
+	maps.Copy(merged, h.events[header])
+	maps.Copy(merged, events)
+
+	names := make([]string, 0, len(merged))
+	detailed := false
+	for name, detail := range merged {
+		names = append(names, name)
+		detailed = detailed || detail != nil
+	}
+	slices.Sort(names)
+	value := strings.Join(names, ", ")
+	if detailed {
+		b, err := json.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		value = string(b)
+	}
+
+	if h.events == nil {
+		h.events = map[string]Events{}
+	}
+	h.events[header] = merged
+	h.Response.Header().Set(header, value)
+	return nil
+}
@@ -0,0 +1,66 @@
+package htmx
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetTrigger(t *testing.T) {
+	type call struct {
+		events  Events
+		wantErr bool
+	}
+
+	tests := []struct {
+		name  string
+		calls []call
+		want  string
+	}{
+		{"names", []call{{events: Events{"b": nil, "a": nil}}}, "a, b"},
+		{"names merged", []call{{events: Events{"a": nil}}, {events: Events{"b": nil}}}, "a, b"},
+		{"detail", []call{{events: Events{"a": map[string]any{"x": 1}}}}, `{"a":{"x":1}}`},
+		{"names merged with detail", []call{{events: Events{"a": nil}}, {events: Events{"b": "x"}}}, `{"a":null,"b":"x"}`},
+		{"detail replaced", []call{{events: Events{"a": 1}}, {events: Events{"a": 2}}}, `{"a":2}`},
+		{"failed call kept out", []call{
+			{events: Events{"a": nil}},
+			{events: Events{"b": make(chan int)}, wantErr: true},
+			{events: Events{"c": nil}},
+		}, "a, c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h := &HTMX{Request: httptest.NewRequest("GET", "/", nil), Response: w}
+
+			for _, c := range tt.calls {
+				if err := h.SetTrigger(c.events); (err != nil) != c.wantErr {
+					t.Fatalf("SetTrigger(%v) err = %v, want error %v", c.events, err, c.wantErr)
+				}
+			}
+			if got := w.Header().Get("HX-Trigger"); got != tt.want {
+				t.Errorf("HX-Trigger = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTriggerHeadersKeptApart(t *testing.T) {
+	w := httptest.NewRecorder()
+	h := &HTMX{Request: httptest.NewRequest("GET", "/", nil), Response: w}
+
+	if err := h.SetTrigger(Events{"a": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetTriggerAfterSwap(Events{"b": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetTriggerAfterSettle(Events{"c": nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	for header, want := range map[string]string{"HX-Trigger": "a", "HX-Trigger-After-Swap": "b", "HX-Trigger-After-Settle": "c"} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
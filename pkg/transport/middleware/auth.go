@@ -2,54 +2,113 @@ package middleware
 
 import (
 	"gin.go.dev/pkg/storage/db/dbx"
-	"github.com/gin-contrib/sessions"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 	sloggin "github.com/samber/slog-gin"
 	"log/slog"
 	"net/http"
-	"sync"
 )
 
 // Authenticated middleware func to ensure logged in, redirects to log-in if not.
+// A user already set by BearerAuth counts as logged in.
 func Authenticated() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var once sync.Once
+		if _, ok := appctx.CurrentUser(c); !ok {
+			setCurrentUser(c)
+		}
+
+		if _, ok := appctx.CurrentUser(c); !ok {
+			unauthenticated(c)
+		}
+	}
+}
 
-		once.Do(func() {
+// Verified middleware func to ensure logged in with a verified email address,
+// redirects to log-in if not logged in or to verify if not verified.
+func Verified() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := appctx.CurrentUser(c); !ok {
 			setCurrentUser(c)
-		})
-
-		if _, exists := c.Get("user"); !exists {
-			hx := c.MustGet("htmx").(*HTMX)
-			if hx.IsHTMXRequest() {
-				hx.SetRedirect("/auth/login")
-				c.Status(http.StatusNoContent)
-			} else {
-				c.Redirect(http.StatusFound, "/auth/login")
+		}
+
+		user, ok := appctx.CurrentUser(c)
+		if !ok {
+			unauthenticated(c)
+			return
+		}
+
+		if !user.IsVerified {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusForbidden, "unverified", "the email address must be verified")
+				return
 			}
-			c.Abort()
+			redirect(c, "/auth/verify")
 		}
 	}
 }
 
+// CurrentUser get the logged-in user, if any, on routes that do not require logging in.
+func CurrentUser(c *gin.Context) (dbx.AuthUser, bool) {
+	if _, ok := appctx.CurrentUser(c); !ok {
+		setCurrentUser(c)
+	}
+	return appctx.CurrentUser(c)
+}
+
+// unauthenticated abort the request as not logged in,
+// unauthorized for JSON otherwise redirecting to log-in.
+func unauthenticated(c *gin.Context) {
+	if respond.WantsJSON(c) {
+		respond.Error(c, http.StatusUnauthorized, "unauthenticated", "log in or use an access token")
+		return
+	}
+	redirect(c, "/auth/login")
+}
+
+// redirect abort the request and redirect, using `HX-Redirect` for HTMX requests.
+func redirect(c *gin.Context, url string) {
+	hx := appctx.HTMX(c)
+	hx.Vary()
+	if hx.IsHTMXRequest() {
+		hx.SetRedirect(url)
+		c.Status(http.StatusNoContent)
+	} else {
+		c.Redirect(http.StatusFound, url)
+	}
+	c.Abort()
+}
+
 // setCurrentUser set the current active user.
+// A session still waiting on the second factor of a login is not treated as logged in.
+// Without a logged-in session the remember me cookie, if any, logs the user back in.
+// While impersonating the admin is set as ctxkey.Impersonator, if they are still active.
 func setCurrentUser(c *gin.Context) {
 	ctx := c.Request.Context()
-	session := c.MustGet("session").(sessions.Session)
-	queries := c.MustGet("queries").(*dbx.Queries)
+	session := appctx.Session(c)
+	queries := appctx.Queries(c)
 
-	userID, ok := session.Get("user_id").([16]byte)
-	if !ok {
+	if session.Get("pending_user_id") != nil {
 		return
 	}
 
-	user, err := queries.GetUserByID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
-	if err != nil || !user.IsActive {
+	var user dbx.AuthUser
+	if userID, ok := session.Get("user_id").([16]byte); ok {
+		var err error
+		user, err = queries.GetUserByID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+		if err != nil || !user.IsActive || !setImpersonator(c) {
+			return
+		}
+	} else if user, ok = rememberUser(c); !ok {
 		return
 	}
 
 	sloggin.AddCustomAttributes(c, slog.String("user", user.Email))
+	if admin, ok := appctx.Impersonator(c); ok {
+		sloggin.AddCustomAttributes(c, slog.String("impersonator", admin.Email))
+	}
 
-	c.Set("user", user)
+	c.Set(ctxkey.User, user)
 }
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"gin.go.dev/pkg/tokens"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+	sloggin "github.com/samber/slog-gin"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// BearerAuth middleware func to authenticate API clients by a personal access token
+// in the Authorization header, setting the same current user as a logged-in session.
+// Requests without a bearer token are left to the session, an invalid token is unauthorized.
+// Only mount it on the JSON API, the HTML routes must not accept a token in place of the session.
+func BearerAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return
+		}
+
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+
+		unauthorized := func() {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			respond.Error(c, http.StatusUnauthorized, "invalid_token", "the access token is invalid or expired")
+		}
+
+		accessToken, err := queries.GetAccessTokenByHash(ctx, tokens.Hash(strings.TrimSpace(token)))
+		if err != nil {
+			unauthorized()
+			return
+		}
+
+		user, err := queries.GetUserByID(ctx, accessToken.UserID)
+		if err != nil || !user.IsActive {
+			unauthorized()
+			return
+		}
+
+		if err = queries.TouchAccessToken(ctx, accessToken.ID); err != nil {
+			_ = c.Error(err)
+		}
+
+		sloggin.AddCustomAttributes(c, slog.String("user", user.Email))
+		sloggin.AddCustomAttributes(c, slog.String("access_token", accessToken.Prefix))
+
+		c.Set(ctxkey.User, user)
+		c.Set(ctxkey.AccessToken, accessToken)
+	}
+}
+
+// RequireScope middleware func to ensure a request authenticated by an access token
+// has the scope, responds forbidden if not.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, scope) {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			respond.Error(c, http.StatusForbidden, "insufficient_scope", "the access token is missing the "+scope+" scope")
+		}
+	}
+}
+
+// HasScope check if the request has the scope,
+// a request authenticated by a session rather than an access token has every scope.
+func HasScope(c *gin.Context, scope string) bool {
+	accessToken, ok := appctx.AccessToken(c)
+	if !ok {
+		return true
+	}
+	return slices.Contains(accessToken.Scopes, scope)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"gin.go.dev/pkg/transport/respond"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
@@ -20,6 +21,10 @@ func AllowContentType(contentTypes ...string) gin.HandlerFunc {
 			c.Next()
 			return
 		}
+		if respond.WantsJSON(c) {
+			respond.Error(c, http.StatusUnsupportedMediaType, "unsupported_media_type", "the content type must be one of "+strings.Join(contentTypes, ", "))
+			return
+		}
 		c.AbortWithStatus(http.StatusUnsupportedMediaType)
 	}
 }
@@ -1,23 +1,34 @@
 package middleware
 
 import (
+	"context"
 	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/htmx"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Context middleware func to set the app context.
+// GinContext middleware func to set the gin context on the request's context,
+// so the appctx accessors work from contexts derived from it.
+// Use it before the sessions middleware, which keeps the request it was given.
+func GinContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), gin.ContextKey, c))
+		c.Next()
+	}
+}
+
+// Context middleware func to set the app context, read with the appctx accessors.
 func Context(postgres *pgxpool.Pool) gin.HandlerFunc {
 	queries := dbx.New(postgres)
 
 	return func(c *gin.Context) {
-		htmx := &HTMX{Request: c.Request, Response: c.Writer}
-
-		c.Set("htmx", htmx)
-		c.Set("postgres", postgres)
-		c.Set("queries", queries)
-		c.Set("session", sessions.Default(c))
+		c.Set(ctxkey.HTMX, &htmx.HTMX{Request: c.Request, Response: c.Writer})
+		c.Set(ctxkey.Postgres, postgres)
+		c.Set(ctxkey.Queries, queries)
+		c.Set(ctxkey.Session, sessions.Default(c))
 
 		c.Next()
 	}
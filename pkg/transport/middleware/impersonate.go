@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"gin.go.dev/pkg/storage/db/dbx"
+	sessionstore "gin.go.dev/pkg/storage/session"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"net/http"
+)
+
+// ImpersonatorIDKey the session value holding the id of the admin impersonating the logged-in user.
+const ImpersonatorIDKey = sessionstore.ImpersonatorIDKey
+
+// Impersonate switch the session to the user, remembering the admin so they can switch back.
+// The session's current organization is cleared to be loaded for the user.
+func Impersonate(c *gin.Context, admin dbx.AuthUser, user dbx.AuthUser) error {
+	session := appctx.Session(c)
+	session.Set(ImpersonatorIDKey, admin.ID.Bytes)
+	session.Set("user_id", user.ID.Bytes)
+	session.Delete("org_id")
+	return session.Save()
+}
+
+// StopImpersonating switch the session back to the admin, returning their id and the impersonated user's.
+// Not ok when the session is not impersonating.
+func StopImpersonating(c *gin.Context) (pgtype.UUID, pgtype.UUID, bool, error) {
+	session := appctx.Session(c)
+
+	adminID, ok := session.Get(ImpersonatorIDKey).([16]byte)
+	if !ok {
+		return pgtype.UUID{}, pgtype.UUID{}, false, nil
+	}
+	userID, _ := session.Get("user_id").([16]byte)
+
+	session.Set("user_id", adminID)
+	session.Delete(ImpersonatorIDKey)
+	session.Delete("org_id")
+	return pgtype.UUID{Bytes: adminID, Valid: true}, pgtype.UUID{Bytes: userID, Valid: true}, true, session.Save()
+}
+
+// Impersonator get the admin impersonating the logged-in user, if any.
+func Impersonator(c *gin.Context) (dbx.AuthUser, bool) {
+	if _, ok := appctx.CurrentUser(c); !ok {
+		setCurrentUser(c)
+	}
+	return appctx.Impersonator(c)
+}
+
+// NotImpersonating middleware func to block sensitive actions, such as changing the account's security,
+// while an admin is impersonating the user.
+func NotImpersonating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := appctx.Session(c)
+		if session.Get(ImpersonatorIDKey) == nil {
+			return
+		}
+
+		if respond.WantsJSON(c) {
+			respond.Error(c, http.StatusForbidden, "impersonating", "this is not allowed while impersonating a user")
+			return
+		}
+		c.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// setImpersonator set the admin impersonating the user, if any.
+// Not ok when the admin can no longer be loaded or was deactivated, ending the impersonation.
+func setImpersonator(c *gin.Context) bool {
+	session := appctx.Session(c)
+	queries := appctx.Queries(c)
+
+	adminID, ok := session.Get(ImpersonatorIDKey).([16]byte)
+	if !ok {
+		return true
+	}
+
+	admin, err := queries.GetUserByID(c.Request.Context(), pgtype.UUID{Bytes: adminID, Valid: true})
+	if err != nil || !admin.IsActive {
+		return false
+	}
+
+	c.Set(ctxkey.Impersonator, admin)
+	return true
+}
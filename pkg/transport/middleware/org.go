@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"gin.go.dev/pkg/storage/db/dbx"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"net/http"
+	"slices"
+)
+
+// CurrentOrg middleware func to load the current organization of the logged-in user
+// and their role in it into the context, as ctxkey.Org and ctxkey.OrgRole.
+// The organization chosen in the session is used while they are a member, otherwise their first,
+// a user without any organization has none set.
+func CurrentOrg() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := appctx.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		queries := appctx.Queries(c)
+		session := appctx.Session(c)
+		userID := user.ID
+
+		if orgID, ok := session.Get("org_id").([16]byte); ok {
+			membership, err := queries.GetMembership(ctx, dbx.GetMembershipParams{
+				OrganizationID: pgtype.UUID{Bytes: orgID, Valid: true},
+				UserID:         userID,
+			})
+			if err == nil {
+				c.Set(ctxkey.Org, membership.Organization)
+				c.Set(ctxkey.OrgRole, membership.Role)
+				return
+			}
+		}
+
+		orgs, err := queries.ListOrganizationsByUserID(ctx, userID)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		if len(orgs) == 0 {
+			session.Delete("org_id")
+			return
+		}
+
+		session.Set("org_id", orgs[0].Organization.ID.Bytes)
+		if err = session.Save(); err != nil {
+			_ = c.Error(err)
+		}
+
+		c.Set(ctxkey.Org, orgs[0].Organization)
+		c.Set(ctxkey.OrgRole, orgs[0].Role)
+	}
+}
+
+// RequireOrg middleware func to ensure the logged-in user has a current organization,
+// with one of the roles if any are given. Without an organization the user is sent to create one,
+// without the role it responds forbidden.
+func RequireOrg(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := appctx.Org(c); !ok {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusForbidden, "no_organization", "create or join an organization first")
+				return
+			}
+			redirect(c, "/orgs/new")
+			return
+		}
+
+		if len(roles) > 0 && !slices.Contains(roles, appctx.OrgRole(c)) {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusForbidden, "forbidden", "your role in the organization does not allow this")
+				return
+			}
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+}
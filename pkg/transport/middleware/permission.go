@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+)
+
+// RequirePermission middleware func to ensure the logged-in user has the permission
+// through one of their roles, redirects to log-in if not logged in otherwise responds forbidden.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := appctx.CurrentUser(c); !ok {
+			setCurrentUser(c)
+		}
+
+		if _, ok := appctx.CurrentUser(c); !ok {
+			unauthenticated(c)
+			return
+		}
+
+		if !HasPermission(c, permission) {
+			if respond.WantsJSON(c) {
+				respond.Error(c, http.StatusForbidden, "forbidden", "the "+permission+" permission is required")
+				return
+			}
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+}
+
+// Permissions get the permissions of the current user, loaded once per request.
+func Permissions(c *gin.Context) []string {
+	if permissions, ok := appctx.Permissions(c); ok {
+		return permissions
+	}
+
+	user, ok := appctx.CurrentUser(c)
+	if !ok {
+		return nil
+	}
+
+	queries := appctx.Queries(c)
+	permissions, err := queries.ListPermissionNamesByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return nil
+	}
+
+	c.Set(ctxkey.Permissions, permissions)
+	return permissions
+}
+
+// HasPermission check if the current user has the permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	return slices.Contains(Permissions(c), permission)
+}
@@ -1,31 +1,120 @@
 package middleware
 
 import (
+	"fmt"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/ratelimit"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"gin.go.dev/pkg/transport/respond"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
+	"github.com/google/uuid"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// RateLimiter provides a Gin middleware that limits request rates by IP address.
-func RateLimiter(r rate.Limit, burst int) gin.HandlerFunc {
-	var sm sync.Map
+// RateLimitKey get the key of the request to limit by, requests without a key are not limited.
+type RateLimitKey func(c *gin.Context) string
 
+// RateLimitByIP limit by the client IP address.
+func RateLimitByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// RateLimitByUser limit by the logged-in user, falling back to the client IP address.
+func RateLimitByUser(c *gin.Context) string {
+	if user, ok := CurrentUser(c); ok {
+		return "user:" + uuid.UUID(user.ID.Bytes).String()
+	}
+	return c.ClientIP()
+}
+
+// RateLimitByEmail limit by the email address in the form.
+func RateLimitByEmail(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(c.PostForm("email")))
+}
+
+// RateLimitByRoute limit every request to the route together.
+func RateLimitByRoute(c *gin.Context) string {
+	return c.FullPath()
+}
+
+// rateLimitKeys the key funcs by their name in the config.
+var rateLimitKeys = map[config.RateLimitKey]RateLimitKey{
+	config.RateLimitKeyIP:    RateLimitByIP,
+	config.RateLimitKeyUser:  RateLimitByUser,
+	config.RateLimitKeyEmail: RateLimitByEmail,
+	config.RateLimitKeyRoute: RateLimitByRoute,
+}
+
+// RateLimits middleware func to set the rate limit backend and config used by RateLimit.
+func RateLimits(limiter ratelimit.Limiter, cfg config.RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		c.Set(ctxkey.RateLimiter, limiter)
+		c.Set(ctxkey.RateLimits, cfg)
+	}
+}
 
-		lim, _ := sm.LoadOrStore(ip, rate.NewLimiter(r, burst))
-		limiter, ok := lim.(*rate.Limiter)
+// RateLimit provides a Gin middleware that limits request rates by the named limit in the config.
+// Limits with the same name share their counts, e.g. every auth route limited by "auth".
+func RateLimit(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := appctx.RateLimits(c).Rule(name)
+		key, ok := rateLimitKeys[rule.Key]
 		if !ok {
-			c.AbortWithStatus(http.StatusInternalServerError)
-			return
+			key = RateLimitByIP
 		}
+		limit := ratelimit.Limit{Requests: rule.Requests, Period: rule.Period()}
+		rateLimit(c, name, limit, key)
+	}
+}
 
-		if limiter.Allow() {
-			c.Next()
-			return
-		}
+// RateLimitBy provides a Gin middleware that limits request rates by the key of the request,
+// e.g. the email address of a form. Requests without a key are not limited.
+func RateLimitBy(name string, limit ratelimit.Limit, key RateLimitKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rateLimit(c, name, limit, key)
+	}
+}
+
+// rateLimit take a request from the key's limit, setting the RateLimit headers,
+// aborting with too many requests and a Retry-After header once exhausted.
+// When the backend fails the request is allowed rather than locking everyone out.
+func rateLimit(c *gin.Context, name string, limit ratelimit.Limit, key RateLimitKey) {
+	k := key(c)
+	if k == "" {
+		return
+	}
+	limiter := appctx.RateLimiter(c)
 
-		c.AbortWithStatus(http.StatusTooManyRequests)
+	result, err := limiter.Allow(c.Request.Context(), name+":"+k, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
+
+	h := c.Writer.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds())))
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", seconds(result.Reset))
+
+	if result.Allowed {
+		return
+	}
+
+	h.Set("Retry-After", seconds(result.RetryAfter))
+	if respond.WantsJSON(c) {
+		respond.Error(c, http.StatusTooManyRequests, "rate_limited", "too many requests, try again later")
+		return
+	}
+	c.AbortWithStatus(http.StatusTooManyRequests)
+}
+
+// seconds the duration in whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"gin.go.dev/pkg/audit"
+	"gin.go.dev/pkg/config"
+	"gin.go.dev/pkg/storage/db/dbx"
+	sessionstore "gin.go.dev/pkg/storage/session"
+	"gin.go.dev/pkg/tokens"
+	"gin.go.dev/pkg/transport/appctx"
+	"gin.go.dev/pkg/transport/ctxkey"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"strings"
+	"time"
+)
+
+// rememberTokenGrace how long the previous validator of a rotated token is still accepted,
+// for requests sent at the same time as the one that rotated it.
+const rememberTokenGrace = 30 * time.Second
+
+// rememberMe the remember me cookie settings.
+type rememberMe struct {
+	config  config.RememberMeConfig
+	session config.SessionConfig
+}
+
+// RememberMe middleware func to enable the remember me cookie, when enabled in the config.
+// The cookie holds a selector to find the token and a validator checked against its hash,
+// the validator is replaced each time it is used so a replayed one reveals a stolen cookie.
+func RememberMe(cfg config.RememberMeConfig, session config.SessionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Enabled {
+			c.Set(ctxkey.RememberMe, cfg)
+			c.Set(ctxkey.RememberMeSession, session)
+		}
+	}
+}
+
+// getRememberMe get the remember me cookie settings if enabled.
+func getRememberMe(c *gin.Context) (*rememberMe, bool) {
+	cfg, session, ok := appctx.RememberMe(c)
+	if !ok {
+		return nil, false
+	}
+	return &rememberMe{config: cfg, session: session}, true
+}
+
+// Remember issue a remember me token for the user, setting its cookie.
+// The token is linked to the logged-in session, so revoking the session revokes it too.
+func Remember(c *gin.Context, userID pgtype.UUID) error {
+	r, ok := getRememberMe(c)
+	if !ok {
+		return nil
+	}
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+
+	selector, err := tokens.Random(12)
+	if err != nil {
+		return err
+	}
+	validator, validatorHash, err := tokens.Generate()
+	if err != nil {
+		return err
+	}
+
+	if _, err = queries.CreateRememberToken(c.Request.Context(), dbx.CreateRememberTokenParams{
+		UserID:        userID,
+		Selector:      selector,
+		ValidatorHash: validatorHash,
+		ExpiresAt:     r.expiresAt(),
+	}); err != nil {
+		return err
+	}
+
+	session.Set(sessionstore.RememberSelectorKey, selector)
+	if err = session.Save(); err != nil {
+		return err
+	}
+
+	r.setCookie(c, selector+"."+validator)
+	return nil
+}
+
+// Forget delete the remember me token of the request, clearing its cookie.
+func Forget(c *gin.Context) error {
+	r, ok := getRememberMe(c)
+	if !ok {
+		return nil
+	}
+	queries := appctx.Queries(c)
+
+	value, err := c.Cookie(r.config.CookieName)
+	if err != nil {
+		return nil
+	}
+	r.clearCookie(c)
+
+	selector, _, _ := strings.Cut(value, ".")
+	return queries.DeleteRememberTokenBySelector(c.Request.Context(), selector)
+}
+
+// rememberUser log the user back in from the remember me cookie, re-establishing the session.
+// A validator that was already replaced is a stolen cookie, all the user's tokens and sessions are revoked.
+func rememberUser(c *gin.Context) (dbx.AuthUser, bool) {
+	r, ok := getRememberMe(c)
+	if !ok {
+		return dbx.AuthUser{}, false
+	}
+
+	ctx := c.Request.Context()
+	queries := appctx.Queries(c)
+	session := appctx.Session(c)
+
+	value, err := c.Cookie(r.config.CookieName)
+	if err != nil || value == "" {
+		return dbx.AuthUser{}, false
+	}
+
+	selector, validator, _ := strings.Cut(value, ".")
+	token, err := queries.GetRememberTokenBySelector(ctx, selector)
+	if err != nil {
+		r.clearCookie(c)
+		return dbx.AuthUser{}, false
+	}
+
+	hash := tokens.Hash(validator)
+	switch {
+	case subtle.ConstantTimeCompare(hash, token.ValidatorHash) == 1:
+		next, nextHash, err := tokens.Generate()
+		if err != nil {
+			_ = c.Error(err)
+			return dbx.AuthUser{}, false
+		}
+		rotated, err := queries.RotateRememberToken(ctx, dbx.RotateRememberTokenParams{
+			NewValidatorHash: nextHash,
+			ExpiresAt:        r.expiresAt(),
+			ID:               token.ID,
+			ValidatorHash:    token.ValidatorHash,
+		})
+		if err != nil {
+			_ = c.Error(err)
+			return dbx.AuthUser{}, false
+		}
+		// otherwise a request sent at the same time rotated it and is setting the cookie
+		if rotated == 1 {
+			r.setCookie(c, selector+"."+next)
+		}
+	case subtle.ConstantTimeCompare(hash, token.PreviousValidatorHash) == 1 &&
+		time.Since(token.RotatedAt.Time) < rememberTokenGrace:
+		// sent at the same time as the request that rotated it, which is setting the cookie
+	default:
+		if err = queries.DeleteRememberTokensByUserID(ctx, token.UserID); err != nil {
+			_ = c.Error(err)
+		}
+		if _, err = queries.DeleteSessionsByUserID(ctx, token.UserID); err != nil {
+			_ = c.Error(err)
+		}
+		audit.RecordRequest(c, audit.Event{
+			Subject: token.UserID,
+			Action:  audit.ActionRememberTokenReused,
+		})
+		r.clearCookie(c)
+		return dbx.AuthUser{}, false
+	}
+
+	user, err := queries.GetUserByID(ctx, token.UserID)
+	if err != nil || !user.IsActive {
+		if err = queries.DeleteRememberTokenBySelector(ctx, selector); err != nil {
+			_ = c.Error(err)
+		}
+		r.clearCookie(c)
+		return dbx.AuthUser{}, false
+	}
+
+	session.Set("user_id", user.ID.Bytes)
+	session.Set(sessionstore.RememberSelectorKey, selector)
+	if err = session.Save(); err != nil {
+		_ = c.Error(err)
+	}
+
+	audit.RecordRequest(c, audit.Event{
+		Actor:    user.ID,
+		Subject:  user.ID,
+		Action:   audit.ActionLogin,
+		Metadata: map[string]any{"method": "remember me"},
+	})
+
+	return user, true
+}
+
+// expiresAt when a token issued or rotated now expires.
+func (r *rememberMe) expiresAt() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().Add(time.Duration(r.config.MaxAge) * time.Second), Valid: true}
+}
+
+// setCookie set the remember me cookie, scoped the same as the session cookie.
+func (r *rememberMe) setCookie(c *gin.Context, value string) {
+	c.SetSameSite(r.session.SameSite)
+	c.SetCookie(r.config.CookieName, value, r.config.MaxAge, r.session.Path, r.session.Domain, r.session.Secure, true)
+}
+
+// clearCookie expire the remember me cookie.
+func (r *rememberMe) clearCookie(c *gin.Context) {
+	c.SetSameSite(r.session.SameSite)
+	c.SetCookie(r.config.CookieName, "", -1, r.session.Path, r.session.Domain, r.session.Secure, true)
+}
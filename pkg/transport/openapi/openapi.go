@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pathParam matches a gin path parameter, e.g. :id.
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// Operation describes a route for the OpenAPI document.
+// Request, Response and Query are example values of the types used, e.g. LoginCredentials{},
+// their json and form tags name the fields and their binding tags become the validation rules.
+type Operation struct {
+	ID          string
+	Summary     string
+	Description string
+	Query       any
+	Request     any
+	Response    any
+	Status      int
+	Paged       bool
+	Public      bool
+}
+
+// Document an OpenAPI 3.1 document built up as routes are described.
+type Document struct {
+	title    string
+	version  string
+	paths    map[string]map[string]operationObject
+	registry *registry
+}
+
+// New create an empty document.
+func New(title string, version string) *Document {
+	return &Document{
+		title:    title,
+		version:  version,
+		paths:    map[string]map[string]operationObject{},
+		registry: newRegistry(),
+	}
+}
+
+// Group a gin router group whose routes are described in the document.
+type Group struct {
+	*gin.RouterGroup
+	doc  *Document
+	tags []string
+}
+
+// Group wrap the gin router group, tagging its operations.
+func (d *Document) Group(g *gin.RouterGroup, tags ...string) *Group {
+	return &Group{RouterGroup: g, doc: d, tags: tags}
+}
+
+// Group create a sub group with the path and handlers, its operations share the group's tags.
+func (g *Group) Group(path string, handlers ...gin.HandlerFunc) *Group {
+	return &Group{RouterGroup: g.RouterGroup.Group(path, handlers...), doc: g.doc, tags: g.tags}
+}
+
+// Handle register the route and describe it.
+func (g *Group) Handle(method string, path string, op Operation, handlers ...gin.HandlerFunc) {
+	g.RouterGroup.Handle(method, path, handlers...)
+	g.doc.add(method, strings.TrimRight(g.BasePath()+path, "/"), g.tags, op)
+}
+
+// GET register and describe a GET route.
+func (g *Group) GET(path string, op Operation, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodGet, path, op, handlers...)
+}
+
+// POST register and describe a POST route.
+func (g *Group) POST(path string, op Operation, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPost, path, op, handlers...)
+}
+
+// PATCH register and describe a PATCH route.
+func (g *Group) PATCH(path string, op Operation, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPatch, path, op, handlers...)
+}
+
+// DELETE register and describe a DELETE route.
+func (g *Group) DELETE(path string, op Operation, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodDelete, path, op, handlers...)
+}
+
+// Envelopes register the schemas of the JSON envelope, the page meta of paged
+// responses and the error envelope of the default response.
+func (d *Document) Envelopes(pageMeta any, errorEnvelope any) {
+	d.registry.page = d.registry.schema(reflect.TypeOf(pageMeta), false)
+	d.registry.error = d.registry.schema(reflect.TypeOf(errorEnvelope), false)
+}
+
+// add describe the operation at the gin path.
+func (d *Document) add(method string, path string, tags []string, op Operation) {
+	o := operationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        tags,
+		Responses:   map[string]responseObject{},
+	}
+	if op.Public {
+		o.Security = &[]map[string][]string{}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		o.Parameters = append(o.Parameters, parameterObject{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	if op.Query != nil {
+		o.Parameters = append(o.Parameters, d.registry.queryParameters(reflect.TypeOf(op.Query))...)
+	}
+
+	if op.Request != nil {
+		o.RequestBody = &requestBodyObject{
+			Required: true,
+			Content:  jsonContent(d.registry.schema(reflect.TypeOf(op.Request), true)),
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := responseObject{Description: http.StatusText(status)}
+	if op.Response != nil {
+		envelope := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": d.registry.schema(reflect.TypeOf(op.Response), false)},
+			Required:   []string{"data"},
+		}
+		if op.Paged && d.registry.page != nil {
+			envelope.Properties["meta"] = d.registry.page
+			envelope.Required = append(envelope.Required, "meta")
+		}
+		response.Content = jsonContent(envelope)
+	}
+	o.Responses[strconv.Itoa(status)] = response
+	if d.registry.error != nil {
+		o.Responses["default"] = responseObject{
+			Description: "An error, identified by its code",
+			Content:     jsonContent(d.registry.error),
+		}
+	}
+
+	path = pathParam.ReplaceAllString(path, "{$1}")
+	if d.paths[path] == nil {
+		d.paths[path] = map[string]operationObject{}
+	}
+	d.paths[path][strings.ToLower(method)] = o
+}
+
+// MarshalJSON the OpenAPI document.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(documentObject{
+		OpenAPI: "3.1.0",
+		Info:    infoObject{Title: d.title, Version: d.version},
+		Paths:   d.paths,
+		Components: componentsObject{
+			Schemas: d.registry.components,
+			SecuritySchemes: map[string]securitySchemeObject{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "A personal access token"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "session", Description: "A logged-in browser session"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}},
+	})
+}
+
+// JSON the OpenAPI document indented, as written to disk.
+func (d *Document) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Handler serve the OpenAPI document.
+func (d *Document) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b, err := d.JSON()
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", b)
+	}
+}
+
+// jsonContent the JSON media type content with the schema.
+func jsonContent(schema *Schema) map[string]mediaTypeObject {
+	return map[string]mediaTypeObject{"application/json": {Schema: schema}}
+}
+
+type documentObject struct {
+	OpenAPI    string                                `json:"openapi"`
+	Info       infoObject                            `json:"info"`
+	Paths      map[string]map[string]operationObject `json:"paths"`
+	Components componentsObject                      `json:"components"`
+	Security   []map[string][]string                 `json:"security"`
+}
+
+type infoObject struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type componentsObject struct {
+	Schemas         map[string]*Schema              `json:"schemas"`
+	SecuritySchemes map[string]securitySchemeObject `json:"securitySchemes"`
+}
+
+type securitySchemeObject struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type operationObject struct {
+	OperationID string                    `json:"operationId,omitempty"`
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Parameters  []parameterObject         `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject        `json:"requestBody,omitempty"`
+	Responses   map[string]responseObject `json:"responses"`
+	Security    *[]map[string][]string    `json:"security,omitempty"`
+}
+
+type parameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBodyObject struct {
+	Required bool                       `json:"required"`
+	Content  map[string]mediaTypeObject `json:"content"`
+}
+
+type responseObject struct {
+	Description string                     `json:"description"`
+	Content     map[string]mediaTypeObject `json:"content,omitempty"`
+}
+
+type mediaTypeObject struct {
+	Schema *Schema `json:"schema"`
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema a JSON schema, as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// registry the named schemas of the document's components,
+// so each struct type is described once and referenced.
+type registry struct {
+	components map[string]*Schema
+	described  map[componentKey]bool
+	page       *Schema
+	error      *Schema
+}
+
+// componentKey identifies a component by its type and whether it describes a request,
+// as the same type's required fields differ between a request and a response.
+type componentKey struct {
+	pkgPath string
+	name    string
+	request bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// newRegistry create an empty registry.
+func newRegistry() *registry {
+	return &registry{components: map[string]*Schema{}, described: map[componentKey]bool{}}
+}
+
+// schema the schema of the type, request types take their required fields from
+// the binding tags while response types require every field not omitted when empty.
+func (r *registry) schema(t reflect.Type, request bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return r.ref(t, request)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", Format: "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &Schema{Type: "array", Items: r.schema(t.Elem(), request)}
+	case t.Kind() == reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem(), request)}
+	case t.Kind() == reflect.String:
+		return &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+// ref register the struct as a component and reference it,
+// anonymous structs are described inline.
+// Components are named by the bare type name, so it panics if two types, or one type
+// used as both a request and a response, would share a name.
+func (r *registry) ref(t reflect.Type, request bool) *Schema {
+	name := t.Name()
+	if name == "" {
+		return r.object(t, request)
+	}
+
+	key := componentKey{pkgPath: t.PkgPath(), name: name, request: request}
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if r.described[key] {
+		return ref
+	}
+	if _, exists := r.components[name]; exists {
+		panic("openapi: the component " + name + " of " + t.PkgPath() + " is already described, by another type or as both a request and a response")
+	}
+
+	// registered before describing the fields so recursive types terminate
+	r.described[key] = true
+	r.components[name] = &Schema{}
+	*r.components[name] = *r.object(t, request)
+	return ref
+}
+
+// object the schema of the struct's fields, embedded structs are flattened as encoding/json does.
+func (r *registry) object(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := fieldName(f)
+		if skip {
+			continue
+		}
+
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			embedded := r.object(f.Type, request)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		field := r.schema(f.Type, request)
+		required := applyBinding(field, f.Tag.Get("binding"))
+		s.Properties[name] = field
+
+		if (request && required) || (!request && !omitEmpty) {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s
+}
+
+// queryParameters the query parameters of the struct's form tagged fields.
+func (r *registry) queryParameters(t reflect.Type) []parameterObject {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []parameterObject
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("form"), ",")[0]
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		schema := r.schema(f.Type, true)
+		required := applyBinding(schema, f.Tag.Get("binding"))
+		params = append(params, parameterObject{
+			Name:        name,
+			In:          "query",
+			Description: f.Tag.Get("doc"),
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+// fieldName the JSON name of the field, falling back to the form name.
+func fieldName(f reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "" {
+		tag = f.Tag.Get("form")
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// applyBinding add the validation rules of a binding tag to the schema,
+// returning if the field is required.
+func applyBinding(s *Schema, binding string) bool {
+	required := false
+
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "uuid":
+			s.Format = "uuid"
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case s.Type == "string" && name == "min":
+				s.MinLength = &n
+			case s.Type == "string":
+				s.MaxLength = &n
+			case s.Type == "array" && name == "min":
+				s.MinItems = &n
+			case s.Type == "array":
+				s.MaxItems = &n
+			case name == "min":
+				f := float64(n)
+				s.Minimum = &f
+			default:
+				f := float64(n)
+				s.Maximum = &f
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				if s.Type == "integer" {
+					if n, err := strconv.Atoi(v); err == nil {
+						s.Enum = append(s.Enum, n)
+					}
+					continue
+				}
+				s.Enum = append(s.Enum, v)
+			}
+		case "eqfield":
+			s.Description = "Must match " + param + "."
+		}
+	}
+
+	return required
+}
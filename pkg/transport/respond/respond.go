@@ -0,0 +1,123 @@
+package respond
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// jsonKey the context key set by JSON to always respond with JSON.
+const jsonKey = "respond_json"
+
+// Envelope the body of a successful JSON response.
+type Envelope struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+// ErrorEnvelope the body of a failed JSON response.
+type ErrorEnvelope struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail the error of a failed JSON response.
+// The code is stable for clients to match on, the message is for people.
+type ErrorDetail struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// PageMeta the meta of a paginated list response.
+type PageMeta struct {
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Total   int64 `json:"total"`
+}
+
+// JSON middleware func to always respond with JSON, for API routes.
+func JSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(jsonKey, true)
+	}
+}
+
+// WantsJSON check if the response should be JSON rather than HTML,
+// either on an API route or by the Accept header preferring JSON.
+func WantsJSON(c *gin.Context) bool {
+	if c.GetBool(jsonKey) {
+		return true
+	}
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// Data respond with the data in the JSON envelope.
+func Data(c *gin.Context, status int, data any) {
+	c.JSON(status, Envelope{Data: data})
+}
+
+// Page respond with a page of a list in the JSON envelope.
+func Page(c *gin.Context, data any, meta PageMeta) {
+	c.JSON(http.StatusOK, Envelope{Data: data, Meta: meta})
+}
+
+// Error abort with the error in the JSON envelope.
+func Error(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, ErrorEnvelope{Error: ErrorDetail{Code: code, Message: message}})
+}
+
+// Invalid abort with a validation error in the JSON envelope,
+// describing each invalid field by its snake case name.
+func Invalid(c *gin.Context, err error) {
+	detail := ErrorDetail{Code: "invalid", Message: "the request is invalid"}
+
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		detail.Fields = make(map[string]string, len(errs))
+		for _, fe := range errs {
+			detail.Fields[snakeCase(fe.Field())] = fieldMessage(fe)
+		}
+	} else if err != nil {
+		detail.Message = "the request body could not be read"
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, ErrorEnvelope{Error: detail})
+}
+
+// fieldMessage describe why a field failed validation.
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param() + " long"
+	case "max":
+		return "must be at most " + fe.Param() + " long"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "eqfield":
+		return "must match " + snakeCase(fe.Param())
+	default:
+		return "is invalid"
+	}
+}
+
+// snakeCase convert a go field name to snake case, e.g. FirstName to first_name.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
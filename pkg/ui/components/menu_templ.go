@@ -10,7 +10,7 @@ import templruntime "github.com/a-h/templ/runtime"
 
 import "gin.go.dev/pkg/ui/icons"
 
-func UserMenu(opened bool) templ.Component {
+func UserMenu(opened bool, permissions []string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -81,7 +81,37 @@ func UserMenu(opened bool) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		if opened {
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"owl-dropdown-menu-label\">My Account</div><div class=\"owl-dropdown-menu-separator\" role=\"separator\"></div><a href=\"/auth/logout\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Logout</a>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"owl-dropdown-menu-label\">My Account</div><div class=\"owl-dropdown-menu-separator\" role=\"separator\"></div><a href=\"/auth/2fa\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Two-factor authentication</a> <a href=\"/auth/passkeys\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Passkeys</a> <a href=\"/auth/sessions\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Active sessions</a> <a href=\"/auth/tokens\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Access tokens</a> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if Can(permissions, "users.manage") || Can(permissions, "audit.view") {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div class=\"owl-dropdown-menu-separator\" role=\"separator\"></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if Can(permissions, "users.manage") {
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<a href=\"/admin/users\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Manage users</a>")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if Can(permissions, "audit.view") {
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<a href=\"/admin/audit\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Audit log</a>")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" <div class=\"owl-dropdown-menu-separator\" role=\"separator\"></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" <a href=\"/auth/logout\" class=\"owl-dropdown-menu-item\" role=\"menuitem\">Logout</a>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -0,0 +1,8 @@
+package components
+
+import "slices"
+
+// Can check if the permissions include the one given, to hide what the user cannot use.
+func Can(permissions []string, permission string) bool {
+	return slices.Contains(permissions, permission)
+}
@@ -0,0 +1,42 @@
+package ctxutil
+
+import (
+	"context"
+	"gin.go.dev/pkg/storage/db/dbx"
+)
+
+// The keys of the request's values in the context templ components are rendered with.
+type (
+	userKey        struct{}
+	permissionsKey struct{}
+	csrfKey        struct{}
+)
+
+// WithUser set the logged-in user and their permissions on the context.
+func WithUser(ctx context.Context, user dbx.AuthUser, permissions []string) context.Context {
+	ctx = context.WithValue(ctx, userKey{}, user)
+	return context.WithValue(ctx, permissionsKey{}, permissions)
+}
+
+// WithCSRF set the request's CSRF token on the context.
+func WithCSRF(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfKey{}, token)
+}
+
+// User get the logged-in user, if any.
+func User(ctx context.Context) (dbx.AuthUser, bool) {
+	user, ok := ctx.Value(userKey{}).(dbx.AuthUser)
+	return user, ok
+}
+
+// Permissions get the permissions of the logged-in user.
+func Permissions(ctx context.Context) []string {
+	permissions, _ := ctx.Value(permissionsKey{}).([]string)
+	return permissions
+}
+
+// CSRF get the CSRF token for forms, empty on routes without the CSRF middleware.
+func CSRF(ctx context.Context) string {
+	token, _ := ctx.Value(csrfKey{}).(string)
+	return token
+}
@@ -8,6 +8,11 @@ package layouts
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+import (
+	"gin.go.dev/pkg/ui/components"
+	"gin.go.dev/pkg/ui/ctxutil"
+)
+
 type Layout struct {
 	Title      string
 	ShowHeader bool
@@ -42,13 +47,13 @@ func Base(l Layout) templ.Component {
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(l.Title)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `pkg/ui/layouts/base.templ`, Line: 17, Col: 37}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `pkg/ui/layouts/base.templ`, Line: 22, Col: 37}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><link rel=\"stylesheet\" href=\"/static/css/global.css\"><script src=\"/static/js/htmx.min.js\" defer></script><script src=\"/static/js/main.js\" defer></script></head>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><link rel=\"stylesheet\" href=\"/static/css/global.css\"><script src=\"/static/js/htmx.min.js\" defer></script><script src=\"/static/js/main.js\" defer></script><script src=\"/static/js/passkeys.js\" defer></script></head>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -75,7 +80,17 @@ func Base(l Layout) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		if l.ShowHeader {
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<header><div class=\"container mx-auto flex p-5 items-center\"><div class=\"owl-h3 mr-auto\"><a href=\"/\">Gin Boilerplate</a></div><div hx-get=\"/auth/user-menu\" hx-swap=\"outerHTML\" hx-trigger=\"load\"></div></div></header>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<div hx-get=\"/admin/impersonation\" hx-swap=\"outerHTML\" hx-trigger=\"load\"></div><header><div class=\"container mx-auto flex p-5 items-center\"><div class=\"owl-h3 mr-auto\"><a href=\"/\">Gin Boilerplate</a></div><div hx-get=\"/orgs/switcher\" hx-swap=\"outerHTML\" hx-trigger=\"load\"></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if _, ok := ctxutil.User(ctx); ok {
+				templ_7745c5c3_Err = components.UserMenu(false, ctxutil.Permissions(ctx)).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div></header>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
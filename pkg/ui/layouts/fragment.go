@@ -0,0 +1,40 @@
+package layouts
+
+import (
+	"context"
+	"github.com/a-h/templ"
+	"io"
+)
+
+// fragmentKey the context key of the fragment being rendered alone.
+type fragmentKey struct{}
+
+// fragment the id of the fragment to render alone, the writer to render it to and whether it was found.
+type fragment struct {
+	id    string
+	w     io.Writer
+	found bool
+}
+
+// Fragment a named part of a page, its children should be the element with the id.
+// Pages render it as usual, RenderFragment renders only its children.
+func Fragment(id string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		children := templ.GetChildren(ctx)
+		ctx = templ.ClearChildren(ctx)
+
+		if f, ok := ctx.Value(fragmentKey{}).(*fragment); ok && !f.found && f.id == id {
+			f.found = true
+			return children.Render(ctx, f.w)
+		}
+		return children.Render(ctx, w)
+	})
+}
+
+// RenderFragment render only the page's fragment with the id to the writer,
+// not found when the page has no such fragment and nothing was written.
+func RenderFragment(ctx context.Context, page templ.Component, id string, w io.Writer) (bool, error) {
+	f := &fragment{id: id, w: w}
+	err := page.Render(context.WithValue(ctx, fragmentKey{}, f), io.Discard)
+	return f.found, err
+}